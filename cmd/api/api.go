@@ -9,48 +9,83 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	_ "time/tzdata" // time zones are validated on import, the runtime image has no tz database
 
 	"github.com/gorilla/mux"
+	"github.com/pkarmon/swiftcodes/internal/apikey"
+	"github.com/pkarmon/swiftcodes/internal/auth"
+	"github.com/pkarmon/swiftcodes/internal/cache"
+	"github.com/pkarmon/swiftcodes/internal/config"
 	"github.com/pkarmon/swiftcodes/internal/csvimport"
 	"github.com/pkarmon/swiftcodes/internal/handlers"
+	"github.com/pkarmon/swiftcodes/internal/memory"
 	"github.com/pkarmon/swiftcodes/internal/middleware"
 	"github.com/pkarmon/swiftcodes/internal/postgres"
+	"github.com/pkarmon/swiftcodes/internal/ratelimit"
+	"github.com/pkarmon/swiftcodes/internal/repo"
 )
 
 func main() {
 	// Load configurations
-	serverCfg := LoadServerConfig()
-	dbConnStr := LoadDatabaseConnectionStr()
-
-	// Connect to database
-	db, err := postgres.Connect(dbConnStr)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer db.Close()
+	serverCfg := config.LoadServerConfig()
+	storageCfg := config.LoadStorageConfig()
 
 	ctx := context.Background()
 
-	// Ping database
-	if err := db.Ping(ctx); err != nil {
-		log.Fatal(err)
-	}
+	var repos repositories
+	switch storageCfg.Backend {
+	case config.StoragePostgres:
+		// Connect to database
+		db, err := postgres.Connect(config.LoadDatabaseConnectionStr())
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
 
-	// Setup database schema
-	if err := db.DropSchema(ctx); err != nil {
-		log.Fatal(err)
+		// Ping database
+		if err := db.Ping(ctx); err != nil {
+			log.Fatal(err)
+		}
+
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			if err := runMigrate(ctx, db, os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		// Apply pending schema migrations
+		applied, err := db.MigrateUp(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Applied %d migration(s)", applied)
+
+		repos = postgresRepositories(db)
+	case config.StorageMemory:
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			log.Fatalf("migrate needs STORAGE_BACKEND=%s", config.StoragePostgres)
+		}
+		log.Println("Keeping data in memory, changes are lost when the server stops")
+		repos = memoryRepositories(memory.NewStore())
+	default:
+		log.Fatalf("STORAGE_BACKEND must be %q or %q, got %q", config.StoragePostgres, config.StorageMemory, storageCfg.Backend)
 	}
-	if err := db.SetupSchema(ctx); err != nil {
+
+	// Import initial data
+	if err := setupInitialData(ctx, repos); err != nil {
 		log.Fatal(err)
 	}
 
-	// Initialize repos and import data
-	if err := setupInitialData(ctx, db); err != nil {
+	authCfg := config.LoadAuthConfig()
+	keyManager := apikey.NewManager(repos.apiKeys, authCfg.KeyCacheTTL)
+	authn, err := setupAuthenticator(authCfg, keyManager)
+	if err != nil {
 		log.Fatal(err)
 	}
 
 	// Configure and start server
-	srv := setupServer(serverCfg, db)
+	srv := setupServer(serverCfg, repos, authn, keyManager)
 	go func() {
 		log.Printf("Starting server on %s", srv.Addr)
 		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
@@ -73,51 +108,152 @@ func main() {
 	}
 }
 
-func setupServer(cfg ServerConfig, db postgres.DB) *http.Server {
-	bankRepo := postgres.NewBankUnitRepo(db)
-	countryRepo := postgres.NewCountryRepo(db)
+// repositories are the repositories of one storage backend.
+type repositories struct {
+	bankUnits  repo.BankUnit
+	countries  repo.Country
+	audit      repo.Audit
+	apiKeys    repo.APIKey
+	importRuns repo.ImportRun
+}
+
+func postgresRepositories(db postgres.DB) repositories {
+	return repositories{
+		bankUnits:  postgres.NewBankUnitRepo(db),
+		countries:  postgres.NewCountryRepo(db),
+		audit:      postgres.NewAuditRepo(db),
+		apiKeys:    postgres.NewAPIKeyRepo(db),
+		importRuns: postgres.NewImportRunRepo(db),
+	}
+}
+
+func memoryRepositories(store *memory.Store) repositories {
+	return repositories{
+		bankUnits:  memory.NewBankUnitRepo(store),
+		countries:  memory.NewCountryRepo(store),
+		audit:      memory.NewAuditRepo(store),
+		apiKeys:    memory.NewAPIKeyRepo(store),
+		importRuns: memory.NewImportRunRepo(store),
+	}
+}
+
+func setupServer(cfg config.ServerConfig, repos repositories, authn *auth.Authenticator, keyManager *apikey.Manager) *http.Server {
+	bankRepo := cache.NewBankUnitRepo(repos.bankUnits, cfg.Cache.Size, cfg.Cache.TTL)
+	countryRepo := repos.countries
+	auditRepo := repos.audit
+
+	reader := func(h http.HandlerFunc) http.HandlerFunc { return middleware.Require(auth.RoleReader, h) }
+	editor := func(h http.HandlerFunc) http.HandlerFunc { return middleware.Require(auth.RoleEditor, h) }
+	admin := func(h http.HandlerFunc) http.HandlerFunc { return middleware.Require(auth.RoleAdmin, h) }
+
+	rateLimit := middleware.RateLimit(middleware.RateLimitOptions{
+		Reads:             ratelimit.NewLimiter(cfg.RateLimit.ReadsPerMinute, cfg.RateLimit.ReadBurst),
+		Writes:            ratelimit.NewLimiter(cfg.RateLimit.WritesPerMinute, cfg.RateLimit.WriteBurst),
+		ReadOnlyPaths:     []string{"/v1/swift-codes/lookup"},
+		TrustForwardedFor: cfg.RateLimit.TrustForwardedFor,
+	})
+	// limits callers by address before their credentials are looked up
+	rateLimitByAddress := middleware.RateLimitByAddress(
+		ratelimit.NewLimiter(cfg.RateLimit.AddressPerMinute, cfg.RateLimit.AddressBurst),
+		cfg.RateLimit.TrustForwardedFor,
+	)
 
 	r := mux.NewRouter()
+	r.HandleFunc("/v1/audit", admin(handlers.GetAuditEvents(auditRepo))).Methods(http.MethodGet)
+	r.HandleFunc("/v1/cache/stats", admin(handlers.GetCacheStats(bankRepo))).Methods(http.MethodGet)
+	r.HandleFunc("/v1/api-keys", admin(handlers.ListAPIKeys(keyManager))).Methods(http.MethodGet)
+	r.HandleFunc("/v1/api-keys", admin(handlers.CreateAPIKey(keyManager))).Methods(http.MethodPost)
+	r.HandleFunc("/v1/api-keys/{id}/rotate", admin(handlers.RotateAPIKey(keyManager))).Methods(http.MethodPost)
+	r.HandleFunc("/v1/api-keys/{id}", admin(handlers.RevokeAPIKey(keyManager))).Methods(http.MethodDelete)
 	api := r.PathPrefix("/v1/swift-codes").Subrouter()
 
 	api.HandleFunc("/country/{countryISO2code}",
-		handlers.GetAllBankUnitsForCountry(bankRepo, countryRepo)).Methods(http.MethodGet)
+		reader(handlers.GetAllBankUnitsForCountry(bankRepo, countryRepo))).Methods(http.MethodGet)
+	api.HandleFunc("/search",
+		reader(handlers.SearchBankUnits(bankRepo))).Methods(http.MethodGet)
+	api.HandleFunc("/match",
+		reader(handlers.MatchBankUnits(bankRepo))).Methods(http.MethodGet)
+	api.HandleFunc("/lookup",
+		reader(handlers.LookupBankUnits(bankRepo))).Methods(http.MethodPost)
+	api.HandleFunc("/bulk",
+		editor(handlers.BulkCreateBankUnits(bankRepo, countryRepo))).Methods(http.MethodPost)
+	api.HandleFunc("/bulk/delete",
+		editor(handlers.BulkDeleteBankUnits(bankRepo))).Methods(http.MethodPost)
+	api.HandleFunc("/deleted",
+		reader(handlers.GetDeletedBankUnits(bankRepo))).Methods(http.MethodGet)
+	api.HandleFunc("/{swiftCode}/restore",
+		editor(handlers.RestoreBankUnit(bankRepo))).Methods(http.MethodPost)
+	api.HandleFunc("/{swiftCode}",
+		reader(handlers.GetBankUnit(bankRepo))).Methods(http.MethodGet)
 	api.HandleFunc("/{swiftCode}",
-		handlers.GetBankUnit(bankRepo)).Methods(http.MethodGet)
+		editor(handlers.UpdateBankUnit(bankRepo))).Methods(http.MethodPut)
 	api.HandleFunc("/{swiftCode}",
-		handlers.DeleteBankUnit(bankRepo)).Methods(http.MethodDelete)
+		editor(handlers.PatchBankUnit(bankRepo))).Methods(http.MethodPatch)
+	api.HandleFunc("/{swiftCode}",
+		editor(handlers.DeleteBankUnit(bankRepo))).Methods(http.MethodDelete)
 	api.HandleFunc("/",
-		handlers.CreateBankUnit(bankRepo, countryRepo)).Methods(http.MethodPost)
+		editor(handlers.CreateBankUnit(bankRepo, countryRepo))).Methods(http.MethodPost)
 
 	return &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
-		Handler:      middleware.Logging(r),
+		Handler:      middleware.Audit(middleware.Logging(rateLimitByAddress(middleware.Authenticate(authn)(rateLimit(r))))),
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
 	}
 }
 
-func setupInitialData(ctx context.Context, db postgres.DB) error {
-	bankRepo := postgres.NewBankUnitRepo(db)
-	countryRepo := postgres.NewCountryRepo(db)
+// setupAuthenticator accepts the static keys from the configuration and the keys
+// issued through keyManager, the static ones are checked first.
+func setupAuthenticator(cfg config.AuthConfig, keyManager *apikey.Manager) (*auth.Authenticator, error) {
+	anonymousRole, err := auth.ParseRole(cfg.AnonymousRole)
+	if err != nil {
+		return nil, fmt.Errorf("AUTH_ANONYMOUS_ROLE: %w", err)
+	}
+
+	keys, err := auth.ParseStaticKeys(cfg.APIKeys)
+	if err != nil {
+		return nil, fmt.Errorf("AUTH_API_KEYS: %w", err)
+	}
+
+	var tokens *auth.JWTVerifier
+	if cfg.JWKSFile != "" {
+		tokens, err = auth.LoadJWKSFile(cfg.JWKSFile, auth.JWTConfig{
+			Issuer:    cfg.JWTIssuer,
+			Audience:  cfg.JWTAudience,
+			RoleClaim: cfg.RoleClaim,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return auth.NewAuthenticator(auth.KeyStores{keys, keyManager}, tokens, anonymousRole), nil
+}
+
+func setupInitialData(ctx context.Context, repos repositories) error {
+	bankRepo := repos.bankUnits
+	countryRepo := repos.countries
+	importRunRepo := repos.importRuns
 
-	countrycodes, err := os.Open("initialData/countries_iso3166b.csv")
+	const countriesPath = "initialData/countries_iso3166b.csv"
+	countrycodes, err := os.Open(countriesPath)
 	if err != nil {
 		return fmt.Errorf("open countries file: %w", err)
 	}
 	defer countrycodes.Close()
 
-	if err := csvimport.Countries(ctx, countrycodes, countryRepo); err != nil {
+	if err := csvimport.SeedCountries(ctx, countriesPath, countrycodes, countryRepo, importRunRepo); err != nil {
 		return fmt.Errorf("import countries: %w", err)
 	}
 
-	bankunits, err := os.Open("initialData/swiftcodes.csv")
+	const bankUnitsPath = "initialData/swiftcodes.csv"
+	bankunits, err := os.Open(bankUnitsPath)
 	if err != nil {
 		return fmt.Errorf("open bank units file: %w", err)
 	}
 	defer bankunits.Close()
 
-	if err := csvimport.BankUnits(ctx, bankunits, bankRepo); err != nil {
+	if err := csvimport.SeedBankUnits(ctx, bankUnitsPath, bankunits, bankRepo, importRunRepo); err != nil {
 		return fmt.Errorf("import bank units: %w", err)
 	}
 
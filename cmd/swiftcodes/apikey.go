@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/apikey"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/postgres"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+func runAPIKey(ctx context.Context, db postgres.DB, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	// The CLI does not authenticate anyone, so nothing is cached.
+	keys := apikey.NewManager(postgres.NewAPIKeyRepo(db), 0)
+	switch args[0] {
+	case "create":
+		return runAPIKeyCreate(ctx, keys, args[1:])
+	case "list":
+		return runAPIKeyList(ctx, keys, args[1:])
+	case "rotate":
+		id, err := parseAPIKeyID(args[1:])
+		if err != nil {
+			return err
+		}
+		key, apiKey, err := keys.Rotate(ctx, id)
+		if errors.Is(err, repo.ErrNotFound) {
+			return fmt.Errorf("no active api key with id %d", id)
+		}
+		if err != nil {
+			return err
+		}
+		printIssuedKey(key, apiKey)
+		return nil
+	case "revoke":
+		id, err := parseAPIKeyID(args[1:])
+		if err != nil {
+			return err
+		}
+		err = keys.Revoke(ctx, id)
+		if errors.Is(err, repo.ErrNotFound) {
+			return fmt.Errorf("no api key with id %d or it is already revoked", id)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Revoked api key %d\n", id)
+		return nil
+	default:
+		return fmt.Errorf("unknown apikey command %q\n%w", args[0], errUsage)
+	}
+}
+
+func runAPIKeyCreate(ctx context.Context, keys *apikey.Manager, args []string) error {
+	fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	owner := fs.String("owner", "", "who the key is issued to")
+	scopes := fs.String("scopes", "", "comma separated roles the key grants")
+	expiresIn := fs.Duration("expires-in", 0, "lifetime of the key, it does not expire when zero")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%v\n%w", err, errUsage)
+	}
+	if *owner == "" || *scopes == "" || fs.NArg() != 0 {
+		return errUsage
+	}
+
+	spec := apikey.Spec{Owner: *owner, Scopes: strings.Split(*scopes, ",")}
+	if *expiresIn != 0 {
+		expiresAt := time.Now().Add(*expiresIn)
+		spec.ExpiresAt = &expiresAt
+	}
+
+	key, apiKey, err := keys.Create(ctx, spec)
+	if err != nil {
+		return err
+	}
+	printIssuedKey(key, apiKey)
+	return nil
+}
+
+func runAPIKeyList(ctx context.Context, keys *apikey.Manager, args []string) error {
+	fs := flag.NewFlagSet("apikey list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	all := fs.Bool("all", false, "include revoked keys")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%v\n%w", err, errUsage)
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	apiKeys, err := keys.List(ctx, *all)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPREFIX\tOWNER\tSCOPES\tCREATED\tEXPIRES\tLAST USED\tREVOKED")
+	for _, k := range apiKeys {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			k.ID, k.Prefix, k.Owner, strings.Join(k.Scopes, ","), formatTime(&k.CreatedAt),
+			formatTime(k.ExpiresAt), formatTime(k.LastUsedAt), formatTime(k.RevokedAt))
+	}
+	return w.Flush()
+}
+
+func parseAPIKeyID(args []string) (int64, error) {
+	if len(args) != 1 {
+		return 0, errUsage
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid api key id %q", args[0])
+	}
+	return id, nil
+}
+
+func printIssuedKey(key string, apiKey *model.APIKey) {
+	fmt.Printf("Issued api key %d for %s (%s), expires: %s\n",
+		apiKey.ID, apiKey.Owner, strings.Join(apiKey.Scopes, ","), formatTime(apiKey.ExpiresAt))
+	fmt.Println("Store the key now, it cannot be shown again:")
+	fmt.Println(key)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/postgres"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+// exportHeader matches the SWIFT directory layout, so an exported file can be imported again.
+var exportHeader = []string{
+	"COUNTRY ISO2 CODE", "SWIFT CODE", "CODE TYPE", "NAME", "ADDRESS", "TOWN NAME", "COUNTRY NAME", "TIME ZONE",
+}
+
+type bankUnitJSON struct {
+	SwiftCode     string `json:"swiftCode"`
+	BankName      string `json:"bankName"`
+	Address       string `json:"address"`
+	CountryISO2   string `json:"countryISO2"`
+	CountryName   string `json:"countryName"`
+	IsHeadquarter bool   `json:"isHeadquarter"`
+	CodeType      string `json:"codeType"`
+	TownName      string `json:"townName"`
+	TimeZone      string `json:"timeZone"`
+}
+
+func toJSON(bu *model.BankUnit) bankUnitJSON {
+	return bankUnitJSON{
+		SwiftCode:     bu.SwiftCode.String(),
+		BankName:      bu.Name,
+		Address:       bu.Address,
+		CountryISO2:   bu.Country.Code.String(),
+		CountryName:   bu.Country.Name,
+		IsHeadquarter: bu.IsHeadquarter,
+		CodeType:      bu.CodeType,
+		TownName:      bu.TownName,
+		TimeZone:      bu.TimeZone,
+	}
+}
+
+func runExport(ctx context.Context, db postgres.DB, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	format := fs.String("format", "csv", "output format, csv or json")
+	output := fs.String("output", "", "output file, stdout when empty")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%v\n%w", err, errUsage)
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	var write func(w io.Writer, units []*model.BankUnit) error
+	switch strings.ToLower(*format) {
+	case "csv":
+		write = writeCSV
+	case "json":
+		write = writeJSON
+	default:
+		return fmt.Errorf("unknown format %q\n%w", *format, errUsage)
+	}
+
+	units, err := postgres.NewBankUnitRepo(db).GetAll(ctx, repo.ReadOptions{})
+	if err != nil {
+		return err
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].SwiftCode.String() < units[j].SwiftCode.String() })
+
+	if *output == "" {
+		return write(os.Stdout, units)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", *output, err)
+	}
+	if err := write(f, units); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeCSV(w io.Writer, units []*model.BankUnit) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportHeader); err != nil {
+		return fmt.Errorf("write csv: %w", err)
+	}
+	for _, bu := range units {
+		err := cw.Write([]string{
+			bu.Country.Code.String(), bu.SwiftCode.String(), bu.CodeType, bu.Name, bu.Address,
+			bu.TownName, bu.Country.Name, bu.TimeZone,
+		})
+		if err != nil {
+			return fmt.Errorf("write csv: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("write csv: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, units []*model.BankUnit) error {
+	out := make([]bankUnitJSON, len(units))
+	for i, bu := range units {
+		out[i] = toJSON(bu)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkarmon/swiftcodes/internal/csvimport"
+	"github.com/pkarmon/swiftcodes/internal/postgres"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+func runImport(ctx context.Context, db postgres.DB, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	kind := args[0]
+
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	diff := fs.Bool("diff", false, "compare with the stored bank units, removing the ones missing from the file")
+	dryRun := fs.Bool("dry-run", false, "only print the changes found by --diff")
+	lenient := fs.Bool("lenient", false, "skip and report invalid bank units instead of failing")
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%v\n%w", err, errUsage)
+	}
+	if fs.NArg() != 1 || (*dryRun && !*diff) || (*diff && kind != "banks") ||
+		(*lenient && (*diff || kind != "banks")) {
+		return errUsage
+	}
+	path := fs.Arg(0)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	importRunRepo := postgres.NewImportRunRepo(db)
+	switch {
+	case kind == "countries":
+		return csvimport.SeedCountries(ctx, path, f, postgres.NewCountryRepo(db), importRunRepo)
+	case kind == "banks" && *diff:
+		report, err := csvimport.DiffBankUnits(ctx, f, postgres.NewBankUnitRepo(db), csvimport.DiffOptions{DryRun: *dryRun})
+		if err != nil {
+			return err
+		}
+		printChangeReport(report)
+		if *dryRun {
+			fmt.Println("Dry run, no changes were applied")
+		}
+		return nil
+	case kind == "banks" && *lenient:
+		return importBankUnitsLenient(ctx, os.Stdout, f, postgres.NewBankUnitRepo(db))
+	case kind == "banks":
+		return csvimport.SeedBankUnits(ctx, path, f, postgres.NewBankUnitRepo(db), importRunRepo)
+	default:
+		return fmt.Errorf("unknown import kind %q\n%w", kind, errUsage)
+	}
+}
+
+func printChangeReport(report *csvimport.ChangeReport) {
+	for _, bu := range report.Added {
+		fmt.Printf("+ %s  %s\n", bu.SwiftCode, bu.Name)
+	}
+	for _, bu := range report.Removed {
+		fmt.Printf("- %s  %s\n", bu.SwiftCode, bu.Name)
+	}
+	for _, m := range report.Modified {
+		fmt.Printf("~ %s  %s  (%s)\n", m.After.SwiftCode, m.After.Name, strings.Join(m.Fields, ", "))
+	}
+	fmt.Printf("%d added, %d removed, %d modified, %d unchanged\n",
+		len(report.Added), len(report.Removed), len(report.Modified), report.Unchanged)
+}
+
+// importBankUnitsLenient creates the valid bank units from src and writes every rejected
+// row to w with its line number, raw record and validation error.
+func importBankUnitsLenient(ctx context.Context, w io.Writer, src io.Reader, r repo.BankUnit) error {
+	report, err := csvimport.BankUnitsLenient(ctx, src, r)
+	if err != nil {
+		return err
+	}
+
+	for _, rejected := range report.Rejected {
+		fmt.Fprintf(w, "! line %d  %s  (%v)\n", rejected.Line, formatRecord(rejected.Record), rejected.Err)
+	}
+	fmt.Fprintf(w, "%d imported, %d rejected\n", report.Imported, len(report.Rejected))
+	return nil
+}
+
+// formatRecord writes the raw fields back as a CSV line.
+func formatRecord(record []string) string {
+	if record == nil {
+		return "<unparsable>"
+	}
+	var b strings.Builder
+	cw := csv.NewWriter(&b)
+	_ = cw.Write(record) // writing to a strings.Builder does not fail
+	cw.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkarmon/swiftcodes/internal/memory"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportBankUnitsLenient(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	pl, err := model.NewCountry("PL", "POLAND")
+	assert.NoError(t, err)
+	assert.NoError(t, memory.NewCountryRepo(store).BulkCreate(ctx, []model.Country{pl}))
+	bankUnitRepo := memory.NewBankUnitRepo(store)
+
+	csvData := `COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE
+PL,BIGBPLPWCUS,BIC11,BANK MILLENNIUM S.A.,"HARMONY CENTER UL. STANISLAWA ZARYNA 2A WARSZAWA, MAZOWIECKIE, 02-593",WARSZAWA,POLAND,Europe/Warsaw
+PL,12$%PL!!XXX,BIC11,BROKEN BANK,NOWHERE,WARSZAWA,POLAND,Europe/Warsaw
+PL,HYVEPLP2XXX,BIC11,PEKAO BANK HIPOTECZNY SA,"RENAISSANCE TOWER, WARSZAWA",WARSZAWA,POLAND,Europe/Warszawa`
+
+	var out bytes.Buffer
+	err = importBankUnitsLenient(ctx, &out, strings.NewReader(csvData), bankUnitRepo)
+
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.True(t, strings.HasPrefix(lines[0],
+			"! line 2  PL,12$%PL!!XXX,BIC11,BROKEN BANK,NOWHERE,WARSZAWA,POLAND,Europe/Warsaw  ("), lines[0])
+		assert.Contains(t, lines[0], model.ErrInvalidInstitutionCode.Error())
+		assert.True(t, strings.HasPrefix(lines[1],
+			`! line 3  PL,HYVEPLP2XXX,BIC11,PEKAO BANK HIPOTECZNY SA,"RENAISSANCE TOWER, WARSZAWA",WARSZAWA,POLAND,Europe/Warszawa  (`), lines[1])
+		assert.Contains(t, lines[1], model.ErrInvalidTimeZone.Error())
+		assert.Equal(t, "1 imported, 2 rejected", lines[2])
+	}
+
+	bankUnits, err := bankUnitRepo.GetAll(ctx, repo.ReadOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, bankUnits, 1) {
+		assert.Equal(t, "BIGBPLPWCUS", bankUnits[0].SwiftCode.String())
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/postgres"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+func runLookup(ctx context.Context, db postgres.DB, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	swiftCode, err := model.NewSwiftCode(args[0])
+	if err != nil {
+		return err
+	}
+
+	bankRepo := postgres.NewBankUnitRepo(db)
+	bu, err := bankRepo.GetBySwiftCode(ctx, swiftCode, repo.ReadOptions{})
+	if errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("swift code %s not found", swiftCode)
+	}
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "SWIFT code:\t%s\n", bu.SwiftCode)
+	fmt.Fprintf(w, "Bank name:\t%s\n", bu.Name)
+	fmt.Fprintf(w, "Address:\t%s\n", bu.Address)
+	fmt.Fprintf(w, "Town:\t%s\n", bu.TownName)
+	fmt.Fprintf(w, "Country:\t%s (%s)\n", bu.Country.Name, bu.Country.Code)
+	fmt.Fprintf(w, "Time zone:\t%s\n", bu.TimeZone)
+	fmt.Fprintf(w, "Headquarter:\t%t\n", bu.IsHeadquarter)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !bu.IsHeadquarter {
+		return nil
+	}
+
+	branches, err := bankRepo.GetBranches(ctx, bu.SwiftCode, repo.ReadOptions{})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nBranches (%d):\n", len(branches))
+	return printTable(branches)
+}
+
+func runList(ctx context.Context, db postgres.DB, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	country := fs.String("country", "", "ISO2 code of the country")
+	excludeTest := fs.Bool("exclude-test", false, "skip test and training BICs")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%v\n%w", err, errUsage)
+	}
+	if *country == "" || fs.NArg() != 0 {
+		return errUsage
+	}
+
+	code, err := model.NewCountryISO2(*country)
+	if err != nil {
+		return err
+	}
+
+	units, err := postgres.NewBankUnitRepo(db).GetAllByCountry(ctx, code, repo.ListOptions{ExcludeTestBICs: *excludeTest})
+	if err != nil {
+		return err
+	}
+
+	return printTable(units)
+}
+
+func printTable(units []*model.BankUnit) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SWIFT CODE\tHQ\tNAME\tTOWN\tADDRESS")
+	for _, bu := range units {
+		hq := ""
+		if bu.IsHeadquarter {
+			hq = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", bu.SwiftCode, hq, bu.Name, bu.TownName, bu.Address)
+	}
+	return w.Flush()
+}
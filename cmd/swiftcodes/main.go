@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	_ "time/tzdata" // time zones are validated on import
+
+	"github.com/pkarmon/swiftcodes/internal/config"
+	"github.com/pkarmon/swiftcodes/internal/postgres"
+)
+
+const usage = `usage: swiftcodes <command> [arguments]
+
+commands:
+  import countries <file>            upsert countries from a CSV file
+  import banks <file>                upsert bank units from a SWIFT directory CSV file
+  import banks --lenient <file>      create bank units, skipping and reporting invalid rows
+  import banks --diff [--dry-run] <file>
+                                     replace the directory with the file, reporting added,
+                                     removed and modified SWIFT codes
+  export [--format csv|json] [--output <file>]
+                                     write all bank units, csv uses the SWIFT directory layout
+  lookup <bic>                       show a single SWIFT code, BIC8 and BIC11 are accepted
+  list --country <XX> [--exclude-test]
+                                     list SWIFT codes of a country
+  apikey create --owner <name> --scopes <roles> [--expires-in <duration>]
+                                     issue an API key, roles are reader, editor and admin
+  apikey list [--all]                list API keys, --all includes revoked ones
+  apikey rotate <id>                 revoke an API key and issue its replacement
+  apikey revoke <id>                 revoke an API key
+
+The database is configured with the same DB_* environment variables as the API.`
+
+var errUsage = errors.New(usage)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var cmd func(ctx context.Context, db postgres.DB, args []string) error
+	switch args[0] {
+	case "import":
+		cmd = runImport
+	case "export":
+		cmd = runExport
+	case "lookup":
+		cmd = runLookup
+	case "list":
+		cmd = runList
+	case "apikey":
+		cmd = runAPIKey
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%w", args[0], errUsage)
+	}
+
+	db, err := postgres.Connect(config.LoadDatabaseConnectionStr())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.Ping(ctx); err != nil {
+		return err
+	}
+
+	return cmd(ctx, db, args[1:])
+}
@@ -0,0 +1,287 @@
+// Package apikey issues API keys kept in the database and authenticates callers
+// presenting them. Keys in use and rejected keys are cached in memory, so
+// authenticating a request usually does not touch the database.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/auth"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+// ErrInvalidSpec is returned for keys that cannot be issued as requested.
+var ErrInvalidSpec = errors.New("invalid API key")
+
+const (
+	// keyMarker starts every issued key, so other strings are refused without a lookup.
+	keyMarker = "sc_"
+	// prefixLen is how much of a key is stored in clear to tell keys apart.
+	prefixLen = len(keyMarker) + 8
+	// maxRejectedTTL caps how long an unknown key is remembered, a key rejected
+	// by mistake is accepted again after that.
+	maxRejectedTTL = 30 * time.Second
+	// maxRejected bounds the rejected keys kept in the cache, so random keys
+	// cannot grow it without limit.
+	maxRejected = 10000
+)
+
+// Spec describes a key to be issued.
+type Spec struct {
+	Owner string
+	// Scopes are role names, the highest of them is granted to the key.
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+// Manager issues, rotates and revokes API keys and implements auth.KeyStore for them.
+type Manager struct {
+	keys     repo.APIKey
+	cacheTTL time.Duration
+
+	mu       sync.Mutex
+	cache    map[string]cachedKey
+	rejected int
+	// generation changes on every revocation, a lookup started before one does
+	// not cache its result as it may be stale.
+	generation uint64
+}
+
+type cachedKey struct {
+	// invalid marks a key that is unknown, revoked or expired.
+	invalid   bool
+	id        int64
+	principal auth.Principal
+	expiresAt *time.Time
+	loadedAt  time.Time
+}
+
+// NewManager returns a manager caching looked up keys for cacheTTL, a zero TTL
+// disables the cache. Rejected keys are cached as well, for at most maxRejectedTTL.
+// Revoking a key drops it from the cache of this manager only, other instances of
+// the API keep accepting it until their cached entry expires.
+func NewManager(keys repo.APIKey, cacheTTL time.Duration) *Manager {
+	return &Manager{keys: keys, cacheTTL: cacheTTL, cache: map[string]cachedKey{}}
+}
+
+// Create issues a new key and returns it together with its description. The key
+// itself is not stored and cannot be shown again.
+func (m *Manager) Create(ctx context.Context, spec Spec) (string, *model.APIKey, error) {
+	scopes, err := spec.validate(time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+
+	key, prefix, err := generateKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	apiKey := &model.APIKey{
+		Prefix:    prefix,
+		Owner:     strings.TrimSpace(spec.Owner),
+		Scopes:    scopes,
+		ExpiresAt: spec.ExpiresAt,
+	}
+	if err := m.keys.Create(ctx, apiKey, auth.HashKey(key)); err != nil {
+		return "", nil, err
+	}
+	return key, apiKey, nil
+}
+
+func (m *Manager) List(ctx context.Context, includeRevoked bool) ([]*model.APIKey, error) {
+	return m.keys.List(ctx, includeRevoked)
+}
+
+// Rotate revokes the key with the id and issues a replacement with the same owner,
+// scopes and expiry. It returns repo.ErrNotFound for unknown, revoked and expired keys.
+func (m *Manager) Rotate(ctx context.Context, id int64) (string, *model.APIKey, error) {
+	key, prefix, err := generateKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	apiKey, err := m.keys.Rotate(ctx, id, prefix, auth.HashKey(key))
+	if err != nil {
+		return "", nil, err
+	}
+	m.invalidate(id)
+	return key, apiKey, nil
+}
+
+// Revoke returns repo.ErrNotFound for unknown and already revoked keys.
+func (m *Manager) Revoke(ctx context.Context, id int64) error {
+	if err := m.keys.Revoke(ctx, id); err != nil {
+		return err
+	}
+	m.invalidate(id)
+	return nil
+}
+
+// Lookup returns the principal of an active key. The last use of a key is
+// recorded when it is loaded into the cache, so it is only as exact as the cache TTL.
+// Rejected keys are cached too, so repeating them does not reach the database.
+func (m *Manager) Lookup(ctx context.Context, key string) (auth.Principal, error) {
+	if !strings.HasPrefix(key, keyMarker) {
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+	hash := auth.HashKey(key)
+	now := time.Now()
+
+	m.mu.Lock()
+	entry, ok := m.cache[hash]
+	if ok && m.expired(entry, now) {
+		m.remove(hash)
+		ok = false
+	}
+	generation := m.generation
+	m.mu.Unlock()
+
+	if ok {
+		if entry.invalid || entry.expiresAt != nil && !now.Before(*entry.expiresAt) {
+			return auth.Principal{}, auth.ErrInvalidCredentials
+		}
+		return entry.principal, nil
+	}
+
+	apiKey, err := m.keys.Use(ctx, hash)
+	if errors.Is(err, repo.ErrNotFound) {
+		m.store(hash, cachedKey{invalid: true, loadedAt: now}, generation)
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		return auth.Principal{}, err
+	}
+
+	principal := auth.Principal{
+		Subject: apiKey.Owner,
+		Role:    highestRole(apiKey.Scopes),
+		KeyID:   strconv.FormatInt(apiKey.ID, 10),
+	}
+	m.store(hash, cachedKey{id: apiKey.ID, principal: principal, expiresAt: apiKey.ExpiresAt, loadedAt: now}, generation)
+	return principal, nil
+}
+
+// store caches the entry unless a key was revoked since generation was read.
+func (m *Manager) store(hash string, entry cachedKey, generation uint64) {
+	if m.cacheTTL <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.generation != generation {
+		return
+	}
+	if entry.invalid {
+		if m.rejected >= maxRejected {
+			m.dropExpired(entry.loadedAt)
+		}
+		if m.rejected >= maxRejected {
+			return
+		}
+	}
+	m.remove(hash)
+	m.cache[hash] = entry
+	if entry.invalid {
+		m.rejected++
+	}
+}
+
+func (m *Manager) expired(entry cachedKey, now time.Time) bool {
+	ttl := m.cacheTTL
+	if entry.invalid {
+		ttl = min(ttl, maxRejectedTTL)
+	}
+	return now.Sub(entry.loadedAt) >= ttl
+}
+
+func (m *Manager) dropExpired(now time.Time) {
+	for hash, entry := range m.cache {
+		if m.expired(entry, now) {
+			m.remove(hash)
+		}
+	}
+}
+
+func (m *Manager) remove(hash string) {
+	if entry, ok := m.cache[hash]; ok {
+		if entry.invalid {
+			m.rejected--
+		}
+		delete(m.cache, hash)
+	}
+}
+
+func (m *Manager) invalidate(id int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.generation++
+	for hash, entry := range m.cache {
+		if !entry.invalid && entry.id == id {
+			m.remove(hash)
+		}
+	}
+}
+
+// validate returns the scopes without duplicates, ordered from the lowest role.
+func (s Spec) validate(now time.Time) ([]string, error) {
+	if strings.TrimSpace(s.Owner) == "" {
+		return nil, fmt.Errorf("%w: owner is required", ErrInvalidSpec)
+	}
+	if len(s.Scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidSpec)
+	}
+	if s.ExpiresAt != nil && !s.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("%w: expiry must be in the future", ErrInvalidSpec)
+	}
+
+	var roles []auth.Role
+	for _, scope := range s.Scopes {
+		role, err := auth.ParseRole(scope)
+		if err != nil || role == auth.RoleNone {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidSpec, scope)
+		}
+		if !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	slices.Sort(roles)
+
+	scopes := make([]string, len(roles))
+	for i, role := range roles {
+		scopes[i] = role.String()
+	}
+	return scopes, nil
+}
+
+func highestRole(scopes []string) auth.Role {
+	highest := auth.RoleNone
+	for _, scope := range scopes {
+		if role, err := auth.ParseRole(scope); err == nil && role > highest {
+			highest = role
+		}
+	}
+	return highest
+}
+
+func generateKey() (key, prefix string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	key = keyMarker + base64.RawURLEncoding.EncodeToString(secret)
+	return key, key[:prefixLen], nil
+}
@@ -0,0 +1,194 @@
+package apikey_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/apikey"
+	"github.com/pkarmon/swiftcodes/internal/auth"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeKeys keeps keys in memory and counts how often they are looked up.
+type fakeKeys struct {
+	byHash map[string]*model.APIKey
+	nextID int64
+	uses   int
+}
+
+func newFakeKeys() *fakeKeys {
+	return &fakeKeys{byHash: map[string]*model.APIKey{}}
+}
+
+func (f *fakeKeys) Create(_ context.Context, key *model.APIKey, secretHash string) error {
+	f.nextID++
+	key.ID = f.nextID
+	key.CreatedAt = time.Now()
+	f.byHash[secretHash] = key
+	return nil
+}
+
+func (f *fakeKeys) Use(_ context.Context, secretHash string) (*model.APIKey, error) {
+	f.uses++
+	key, ok := f.byHash[secretHash]
+	if !ok || !key.Active(time.Now()) {
+		return nil, repo.ErrNotFound
+	}
+	now := time.Now()
+	key.LastUsedAt = &now
+	return key, nil
+}
+
+func (f *fakeKeys) List(_ context.Context, includeRevoked bool) ([]*model.APIKey, error) {
+	var keys []*model.APIKey
+	for _, key := range f.byHash {
+		if includeRevoked || key.RevokedAt == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (f *fakeKeys) Rotate(ctx context.Context, id int64, prefix, secretHash string) (*model.APIKey, error) {
+	for _, key := range f.byHash {
+		if key.ID == id && key.Active(time.Now()) {
+			now := time.Now()
+			key.RevokedAt = &now
+			rotated := &model.APIKey{Prefix: prefix, Owner: key.Owner, Scopes: key.Scopes, ExpiresAt: key.ExpiresAt, RotatedFrom: &id}
+			return rotated, f.Create(ctx, rotated, secretHash)
+		}
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (f *fakeKeys) Revoke(_ context.Context, id int64) error {
+	for _, key := range f.byHash {
+		if key.ID == id && key.RevokedAt == nil {
+			now := time.Now()
+			key.RevokedAt = &now
+			return nil
+		}
+	}
+	return repo.ErrNotFound
+}
+
+func TestCreate(t *testing.T) {
+	m := apikey.NewManager(newFakeKeys(), time.Minute)
+	ctx := context.Background()
+
+	key, apiKey, err := m.Create(ctx, apikey.Spec{Owner: " ci ", Scopes: []string{"editor", "reader", "editor"}})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, apiKey.Prefix))
+	assert.Equal(t, "ci", apiKey.Owner)
+	assert.Equal(t, []string{"reader", "editor"}, apiKey.Scopes)
+
+	past := time.Now().Add(-time.Hour)
+	invalid := map[string]apikey.Spec{
+		"invalid API key: owner is required":              {Scopes: []string{"reader"}},
+		"invalid API key: at least one scope is required": {Owner: "ci"},
+		`invalid API key: unknown scope "none"`:           {Owner: "ci", Scopes: []string{"none"}},
+		`invalid API key: unknown scope "root"`:           {Owner: "ci", Scopes: []string{"reader", "root"}},
+		"invalid API key: expiry must be in the future":   {Owner: "ci", Scopes: []string{"reader"}, ExpiresAt: &past},
+	}
+	for msg, spec := range invalid {
+		_, _, err := m.Create(ctx, spec)
+		assert.ErrorIs(t, err, apikey.ErrInvalidSpec)
+		assert.EqualError(t, err, msg)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("cached until revoked", func(t *testing.T) {
+		keys := newFakeKeys()
+		m := apikey.NewManager(keys, time.Minute)
+		key, apiKey, err := m.Create(ctx, apikey.Spec{Owner: "ci", Scopes: []string{"reader", "editor"}})
+		assert.NoError(t, err)
+
+		for range 3 {
+			p, err := m.Lookup(ctx, key)
+			assert.NoError(t, err)
+			assert.Equal(t, auth.Principal{Subject: "ci", Role: auth.RoleEditor, KeyID: strconv.FormatInt(apiKey.ID, 10)}, p)
+		}
+		assert.Equal(t, 1, keys.uses)
+		assert.NotNil(t, apiKey.LastUsedAt)
+
+		assert.NoError(t, m.Revoke(ctx, apiKey.ID))
+		_, err = m.Lookup(ctx, key)
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+		assert.ErrorIs(t, m.Revoke(ctx, apiKey.ID), repo.ErrNotFound)
+	})
+
+	t.Run("rotated key replaces the old one", func(t *testing.T) {
+		m := apikey.NewManager(newFakeKeys(), time.Minute)
+		key, apiKey, err := m.Create(ctx, apikey.Spec{Owner: "ci", Scopes: []string{"admin"}})
+		assert.NoError(t, err)
+		_, err = m.Lookup(ctx, key)
+		assert.NoError(t, err)
+
+		rotatedKey, rotated, err := m.Rotate(ctx, apiKey.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, apiKey.ID, *rotated.RotatedFrom)
+		assert.NotEqual(t, key, rotatedKey)
+
+		_, err = m.Lookup(ctx, key)
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+		p, err := m.Lookup(ctx, rotatedKey)
+		assert.NoError(t, err)
+		assert.Equal(t, auth.RoleAdmin, p.Role)
+
+		_, _, err = m.Rotate(ctx, apiKey.ID)
+		assert.ErrorIs(t, err, repo.ErrNotFound)
+	})
+
+	t.Run("without cache", func(t *testing.T) {
+		keys := newFakeKeys()
+		m := apikey.NewManager(keys, 0)
+		key, _, err := m.Create(ctx, apikey.Spec{Owner: "ci", Scopes: []string{"reader"}})
+		assert.NoError(t, err)
+
+		for range 2 {
+			_, err := m.Lookup(ctx, key)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 2, keys.uses)
+	})
+
+	t.Run("unknown keys", func(t *testing.T) {
+		keys := newFakeKeys()
+		m := apikey.NewManager(keys, time.Minute)
+
+		for range 3 {
+			_, err := m.Lookup(ctx, "sc_unknown")
+			assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+		}
+		_, err := m.Lookup(ctx, "not-an-issued-key")
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+		assert.Equal(t, 1, keys.uses)
+	})
+
+	t.Run("rejected keys are cached shortly", func(t *testing.T) {
+		keys := newFakeKeys()
+		m := apikey.NewManager(keys, 20*time.Millisecond)
+		key, apiKey, err := m.Create(ctx, apikey.Spec{Owner: "ci", Scopes: []string{"reader"}})
+		assert.NoError(t, err)
+		assert.NoError(t, m.Revoke(ctx, apiKey.ID))
+
+		for range 3 {
+			_, err := m.Lookup(ctx, key)
+			assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+		}
+		assert.Equal(t, 1, keys.uses)
+
+		time.Sleep(30 * time.Millisecond)
+		_, err = m.Lookup(ctx, key)
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+		assert.Equal(t, 2, keys.uses)
+	})
+}
@@ -0,0 +1,41 @@
+// Package audit carries who is making a change, and as part of which request,
+// from the HTTP layer down to the repositories that record audit events.
+package audit
+
+import "context"
+
+const (
+	// ActorSystem is used for changes made outside of an HTTP request, e.g. by the CLI.
+	ActorSystem = "system"
+	// ActorAnonymous is used for API requests that do not identify the caller.
+	ActorAnonymous = "anonymous"
+)
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	requestIDKey
+)
+
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// Actor returns the actor stored in ctx, ActorSystem when there is none.
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey).(string); ok && actor != "" {
+		return actor
+	}
+	return ActorSystem
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request id stored in ctx or an empty string.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
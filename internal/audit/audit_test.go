@@ -0,0 +1,19 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkarmon/swiftcodes/internal/audit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, audit.ActorSystem, audit.Actor(ctx))
+	assert.Equal(t, "", audit.RequestID(ctx))
+
+	ctx = audit.WithRequestID(audit.WithActor(ctx, "alice"), "req-1")
+	assert.Equal(t, "alice", audit.Actor(ctx))
+	assert.Equal(t, "req-1", audit.RequestID(ctx))
+}
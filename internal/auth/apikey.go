@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeyStore resolves API keys to the principals they were issued to.
+type KeyStore interface {
+	// Lookup returns ErrInvalidCredentials for unknown keys.
+	Lookup(ctx context.Context, key string) (Principal, error)
+}
+
+// HashKey returns the hash API keys are stored and compared by, so the keys
+// themselves never have to be kept.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// StaticKeyStore holds a fixed set of API keys given in the configuration.
+type StaticKeyStore struct {
+	byHash map[string]Principal
+}
+
+// ParseStaticKeys reads comma separated subject:role:key entries.
+func ParseStaticKeys(s string) (*StaticKeyStore, error) {
+	store := &StaticKeyStore{byHash: map[string]Principal{}}
+	for i, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid API key entry %d, expected subject:role:key", i+1)
+		}
+		role, err := ParseRole(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid API key entry for %s: %w", parts[0], err)
+		}
+		store.byHash[HashKey(parts[2])] = Principal{Subject: parts[0], Role: role, KeyID: fmt.Sprintf("static:%d", i+1)}
+	}
+	return store, nil
+}
+
+func (s *StaticKeyStore) Lookup(_ context.Context, key string) (Principal, error) {
+	p, ok := s.byHash[HashKey(key)]
+	if !ok {
+		return Principal{}, ErrInvalidCredentials
+	}
+	return p, nil
+}
+
+// KeyStores looks a key up in each store in turn, the first store knowing it wins.
+type KeyStores []KeyStore
+
+func (s KeyStores) Lookup(ctx context.Context, key string) (Principal, error) {
+	for _, store := range s {
+		p, err := store.Lookup(ctx, key)
+		if !errors.Is(err, ErrInvalidCredentials) {
+			return p, err
+		}
+	}
+	return Principal{}, ErrInvalidCredentials
+}
@@ -0,0 +1,105 @@
+// Package auth authenticates API callers with API keys or JWT bearer tokens and
+// describes what they are allowed to do with roles.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUnknownRole        = errors.New("unknown role")
+)
+
+// Role grants access to a group of endpoints, every role includes the ones below it.
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleReader
+	RoleEditor
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleNone:   "none",
+	RoleReader: "reader",
+	RoleEditor: "editor",
+	RoleAdmin:  "admin",
+}
+
+func ParseRole(s string) (Role, error) {
+	for role, name := range roleNames {
+		if name == s {
+			return role, nil
+		}
+	}
+	return RoleNone, fmt.Errorf("%w %q", ErrUnknownRole, s)
+}
+
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+// Includes reports whether r grants everything the other role does.
+func (r Role) Includes(other Role) bool {
+	return r >= other
+}
+
+// Principal is the caller of a request.
+type Principal struct {
+	Subject string
+	Role    Role
+	// KeyID tells apart the API keys of a subject, it is the id of an issued key or
+	// static:<n> for the n-th configured one, and empty for bearer tokens.
+	KeyID string
+	// Anonymous is set for callers that did not present any credentials.
+	Anonymous bool
+}
+
+type contextKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// PrincipalFrom returns the principal stored in ctx by WithPrincipal.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
+
+// Authenticator checks the credentials presented by callers. Either way of
+// authenticating can be left out by passing nil.
+type Authenticator struct {
+	keys          KeyStore
+	tokens        *JWTVerifier
+	anonymousRole Role
+}
+
+// NewAuthenticator returns an authenticator accepting API keys from keys and bearer
+// tokens verified by tokens. Callers without credentials get anonymousRole.
+func NewAuthenticator(keys KeyStore, tokens *JWTVerifier, anonymousRole Role) *Authenticator {
+	return &Authenticator{keys: keys, tokens: tokens, anonymousRole: anonymousRole}
+}
+
+func (a *Authenticator) AuthenticateAPIKey(ctx context.Context, key string) (Principal, error) {
+	if a.keys == nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+	return a.keys.Lookup(ctx, key)
+}
+
+func (a *Authenticator) AuthenticateToken(token string) (Principal, error) {
+	if a.tokens == nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+	return a.tokens.Verify(token)
+}
+
+// Anonymous returns the principal of callers without credentials.
+func (a *Authenticator) Anonymous() Principal {
+	return Principal{Subject: "anonymous", Role: a.anonymousRole, Anonymous: true}
+}
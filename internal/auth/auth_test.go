@@ -0,0 +1,207 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRole(t *testing.T) {
+	for _, name := range []string{"none", "reader", "editor", "admin"} {
+		role, err := auth.ParseRole(name)
+		assert.NoError(t, err)
+		assert.Equal(t, name, role.String())
+	}
+
+	_, err := auth.ParseRole("owner")
+	assert.ErrorIs(t, err, auth.ErrUnknownRole)
+
+	assert.True(t, auth.RoleAdmin.Includes(auth.RoleEditor))
+	assert.True(t, auth.RoleEditor.Includes(auth.RoleEditor))
+	assert.False(t, auth.RoleReader.Includes(auth.RoleEditor))
+}
+
+func TestStaticKeyStore(t *testing.T) {
+	store, err := auth.ParseStaticKeys("ci:editor:s3cret, ops:admin:0ps:with:colons")
+	assert.NoError(t, err)
+
+	p, err := store.Lookup(context.Background(), "s3cret")
+	assert.NoError(t, err)
+	assert.Equal(t, auth.Principal{Subject: "ci", Role: auth.RoleEditor, KeyID: "static:1"}, p)
+
+	p, err = store.Lookup(context.Background(), "0ps:with:colons")
+	assert.NoError(t, err)
+	assert.Equal(t, "ops", p.Subject)
+	assert.Equal(t, "static:2", p.KeyID)
+
+	_, err = store.Lookup(context.Background(), "guess")
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+
+	_, err = auth.ParseStaticKeys("ci:owner:s3cret")
+	assert.ErrorIs(t, err, auth.ErrUnknownRole)
+	_, err = auth.ParseStaticKeys("just-a-key")
+	assert.EqualError(t, err, "invalid API key entry 1, expected subject:role:key")
+}
+
+func TestKeyStores(t *testing.T) {
+	first, err := auth.ParseStaticKeys("ci:editor:shared,ops:admin:ops-key")
+	assert.NoError(t, err)
+	second, err := auth.ParseStaticKeys("other:reader:shared,viewer:reader:viewer-key")
+	assert.NoError(t, err)
+	stores := auth.KeyStores{first, second}
+
+	p, err := stores.Lookup(context.Background(), "shared")
+	assert.NoError(t, err)
+	assert.Equal(t, "ci", p.Subject)
+
+	p, err = stores.Lookup(context.Background(), "viewer-key")
+	assert.NoError(t, err)
+	assert.Equal(t, "viewer", p.Subject)
+
+	_, err = stores.Lookup(context.Background(), "guess")
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+}
+
+type signer struct {
+	kid string
+	alg string
+	key crypto.Signer
+}
+
+func (s signer) sign(t *testing.T, claims map[string]any) string {
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		assert.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	input := encode(map[string]string{"alg": s.alg, "kid": s.kid, "typ": "JWT"}) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch key := s.key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		assert.NoError(t, err)
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestJWTVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	ecPub, err := ecKey.PublicKey.ECDH()
+	assert.NoError(t, err)
+	point := ecPub.Bytes() // uncompressed: 0x04 || X || Y
+
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(point[1:33]), "y": b64(point[33:])},
+		{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+	}})
+	assert.NoError(t, err)
+
+	verifier, err := auth.ParseJWKS(jwks, auth.JWTConfig{Issuer: "https://idp.example", Audience: "swiftcodes"})
+	assert.NoError(t, err)
+
+	rs256 := signer{kid: "rsa", alg: "RS256", key: rsaKey}
+	es256 := signer{kid: "ec", alg: "ES256", key: ecKey}
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"sub":  "alice",
+			"iss":  "https://idp.example",
+			"aud":  []string{"other", "swiftcodes"},
+			"exp":  time.Now().Add(time.Hour).Unix(),
+			"role": "editor",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	t.Run("valid tokens", func(t *testing.T) {
+		p, err := verifier.Verify(rs256.sign(t, claims(nil)))
+		assert.NoError(t, err)
+		assert.Equal(t, auth.Principal{Subject: "alice", Role: auth.RoleEditor}, p)
+
+		p, err = verifier.Verify(es256.sign(t, claims(map[string]any{"aud": "swiftcodes"})))
+		assert.NoError(t, err)
+		assert.Equal(t, auth.RoleEditor, p.Role)
+	})
+
+	t.Run("highest role of a list", func(t *testing.T) {
+		p, err := verifier.Verify(rs256.sign(t, claims(map[string]any{"role": []string{"reader", "admin", "janitor"}})))
+		assert.NoError(t, err)
+		assert.Equal(t, auth.RoleAdmin, p.Role)
+	})
+
+	t.Run("missing role", func(t *testing.T) {
+		p, err := verifier.Verify(rs256.sign(t, claims(map[string]any{"role": nil})))
+		assert.NoError(t, err)
+		assert.Equal(t, auth.RoleNone, p.Role)
+	})
+
+	t.Run("invalid tokens", func(t *testing.T) {
+		tampered := rs256.sign(t, claims(nil))
+		tampered = tampered[:len(tampered)-4] + "AAAA"
+
+		tokens := map[string]string{
+			"expired":         rs256.sign(t, claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})),
+			"no exp":          rs256.sign(t, claims(map[string]any{"exp": nil})),
+			"not yet valid":   rs256.sign(t, claims(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()})),
+			"no subject":      rs256.sign(t, claims(map[string]any{"sub": nil})),
+			"wrong issuer":    rs256.sign(t, claims(map[string]any{"iss": "https://evil.example"})),
+			"wrong audience":  rs256.sign(t, claims(map[string]any{"aud": "other"})),
+			"unknown key":     signer{kid: "nope", alg: "RS256", key: rsaKey}.sign(t, claims(nil)),
+			"other key":       signer{kid: "rsa", alg: "RS256", key: otherKey}.sign(t, claims(nil)),
+			"alg mismatch":    signer{kid: "rsa", alg: "ES256", key: ecKey}.sign(t, claims(nil)),
+			"unsupported alg": signer{kid: "rsa", alg: "none", key: rsaKey}.sign(t, claims(nil)),
+			"tampered":        tampered,
+			"malformed":       "not-a-token",
+		}
+		for name, token := range tokens {
+			_, err := verifier.Verify(token)
+			assert.ErrorIs(t, err, auth.ErrInvalidCredentials, name)
+		}
+	})
+
+	t.Run("jwks without usable keys", func(t *testing.T) {
+		_, err := auth.ParseJWKS([]byte(`{"keys": [{"kty": "oct", "k": "c2VjcmV0"}]}`), auth.JWTConfig{})
+		assert.Error(t, err)
+	})
+}
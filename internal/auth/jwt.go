@@ -0,0 +1,293 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// clockSkew is tolerated when checking the exp and nbf claims.
+const clockSkew = time.Minute
+
+// JWTConfig configures how bearer tokens are verified.
+type JWTConfig struct {
+	// Issuer and Audience are checked against the iss and aud claims when set.
+	Issuer   string
+	Audience string
+	// RoleClaim names the claim holding the role, a string or a list of strings
+	// of which the highest known role is used.
+	RoleClaim string
+}
+
+// JWTVerifier verifies RS256 and ES256 signed tokens against a fixed set of keys,
+// without calling out to the issuer.
+type JWTVerifier struct {
+	keys map[string]crypto.PublicKey
+	cfg  JWTConfig
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// LoadJWKSFile reads a JSON Web Key Set, only RSA and P-256 EC keys are used.
+func LoadJWKSFile(path string, cfg JWTConfig) (*JWTVerifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	return ParseJWKS(data, cfg)
+}
+
+func ParseJWKS(data []byte, cfg JWTConfig) (*JWTVerifier, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS does not contain any RSA or P-256 keys")
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "role"
+	}
+
+	return &JWTVerifier{keys: keys, cfg: cfg}, nil
+}
+
+// publicKey returns nil for key types that are not supported.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, nil
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !key.Curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the P-256 curve")
+		}
+		return key, nil
+	}
+	return nil, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url number")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+}
+
+// Verify checks the signature and the registered claims of token and returns the
+// principal it was issued to. Every failure is reported as ErrInvalidCredentials.
+func (v *JWTVerifier) Verify(token string) (Principal, error) {
+	p, err := v.verify(token)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return p, nil
+}
+
+func (v *JWTVerifier) verify(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, err
+	}
+	key, ok := v.keys[header.Kid]
+	if !ok {
+		return Principal{}, fmt.Errorf("unknown key id %q", header.Kid)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, errors.New("malformed signature")
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return Principal{}, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, err
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return Principal{}, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &all); err != nil {
+		return Principal{}, err
+	}
+
+	return Principal{Subject: claims.Subject, Role: roleFromClaim(all[v.cfg.RoleClaim])}, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("malformed token segment")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("malformed token segment")
+	}
+	return nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signingInput string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("algorithm does not match the key")
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("invalid signature")
+		}
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("algorithm does not match the key")
+		}
+		if len(signature) != 64 {
+			return errors.New("invalid signature")
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	return nil
+}
+
+func (v *JWTVerifier) checkClaims(claims jwtClaims) error {
+	now := time.Now()
+	if claims.Subject == "" {
+		return errors.New("missing sub claim")
+	}
+	if claims.ExpiresAt == nil {
+		return errors.New("missing exp claim")
+	}
+	if now.After(unixTime(*claims.ExpiresAt).Add(clockSkew)) {
+		return errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(clockSkew).Before(unixTime(*claims.NotBefore)) {
+		return errors.New("token not valid yet")
+	}
+	if v.cfg.Issuer != "" && claims.Issuer != v.cfg.Issuer {
+		return errors.New("unexpected issuer")
+	}
+	if v.cfg.Audience != "" && !hasAudience(claims.Audience, v.cfg.Audience) {
+		return errors.New("unexpected audience")
+	}
+	return nil
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// hasAudience checks the aud claim, which is either a string or a list of strings.
+func hasAudience(raw json.RawMessage, audience string) bool {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single == audience
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		for _, aud := range list {
+			if aud == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// roleFromClaim returns the highest known role in the claim, RoleNone when there is none.
+func roleFromClaim(raw json.RawMessage) Role {
+	var names []string
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		names = []string{single}
+	} else if err := json.Unmarshal(raw, &names); err != nil {
+		return RoleNone
+	}
+
+	highest := RoleNone
+	for _, name := range names {
+		if role, err := ParseRole(name); err == nil && role > highest {
+			highest = role
+		}
+	}
+	return highest
+}
@@ -0,0 +1,219 @@
+// Package cache keeps recently read data in memory in front of the repositories.
+package cache
+
+import (
+	"context"
+	"iter"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+// Entries are keyed by their kind and the canonical swift code, e.g. "unit:BPKOPLPWXXX".
+const (
+	unitKey     = "unit:"
+	branchesKey = "branches:"
+)
+
+// Stats describes how well the cache is doing since it was created.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+}
+
+// BankUnitRepo caches GetBySwiftCode and GetBranches of the current, live bank units,
+// reads with other options and all other methods go to the wrapped repository.
+// Every write drops the entries of the bank units it touches together with the
+// branch lists of their institution, imports and DeleteAll drop everything.
+//
+// Only writes made through this repository are seen, changes made by other
+// instances of the API or the command line tool show up once the entries expire.
+type BankUnitRepo struct {
+	repo.BankUnit
+
+	mu      sync.Mutex
+	entries *lru
+	stats   Stats
+	// generation changes on every invalidation, a read started before one does not
+	// cache its result as it may be stale.
+	generation uint64
+}
+
+// NewBankUnitRepo caches up to size entries for ttl, nothing is cached when size is
+// not positive.
+func NewBankUnitRepo(bankUnits repo.BankUnit, size int, ttl time.Duration) *BankUnitRepo {
+	return &BankUnitRepo{BankUnit: bankUnits, entries: newLRU(size, ttl)}
+}
+
+func (r *BankUnitRepo) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.stats
+	stats.Entries = r.entries.len()
+	return stats
+}
+
+func (r *BankUnitRepo) GetBySwiftCode(ctx context.Context, swiftCode model.SwiftCode, opts repo.ReadOptions) (*model.BankUnit, error) {
+	if opts.IncludeDeleted || !opts.AsOf.IsZero() {
+		return r.BankUnit.GetBySwiftCode(ctx, swiftCode, opts)
+	}
+
+	key := unitKey + swiftCode.Canonical().String()
+	v, generation, ok := r.lookup(key)
+	if ok {
+		return cloneBankUnit(v.(*model.BankUnit)), nil
+	}
+
+	bankUnit, err := r.BankUnit.GetBySwiftCode(ctx, swiftCode, opts)
+	if err != nil {
+		return nil, err
+	}
+	r.store(key, cloneBankUnit(bankUnit), generation)
+	return bankUnit, nil
+}
+
+func (r *BankUnitRepo) GetBranches(ctx context.Context, swiftCode model.SwiftCode, opts repo.ReadOptions) ([]*model.BankUnit, error) {
+	if opts.IncludeDeleted || !opts.AsOf.IsZero() {
+		return r.BankUnit.GetBranches(ctx, swiftCode, opts)
+	}
+
+	key := branchesKey + swiftCode.Canonical().String()
+	v, generation, ok := r.lookup(key)
+	if ok {
+		return cloneBankUnits(v.([]*model.BankUnit)), nil
+	}
+
+	branches, err := r.BankUnit.GetBranches(ctx, swiftCode, opts)
+	if err != nil {
+		return nil, err
+	}
+	r.store(key, cloneBankUnits(branches), generation)
+	return branches, nil
+}
+
+func (r *BankUnitRepo) Create(ctx context.Context, bankUnit *model.BankUnit) error {
+	defer r.invalidate(bankUnit.SwiftCode)
+	return r.BankUnit.Create(ctx, bankUnit)
+}
+
+func (r *BankUnitRepo) BulkCreate(ctx context.Context, bankUnits []*model.BankUnit) error {
+	defer r.invalidate(swiftCodesOf(bankUnits)...)
+	return r.BankUnit.BulkCreate(ctx, bankUnits)
+}
+
+func (r *BankUnitRepo) BulkCreateFrom(ctx context.Context, bankUnits iter.Seq2[*model.BankUnit, error]) (int, error) {
+	defer r.invalidateAll()
+	return r.BankUnit.BulkCreateFrom(ctx, bankUnits)
+}
+
+func (r *BankUnitRepo) BulkUpsert(ctx context.Context, bankUnits iter.Seq2[*model.BankUnit, error]) (int, error) {
+	defer r.invalidateAll()
+	return r.BankUnit.BulkUpsert(ctx, bankUnits)
+}
+
+func (r *BankUnitRepo) Update(ctx context.Context, bankUnit *model.BankUnit) error {
+	defer r.invalidate(bankUnit.SwiftCode)
+	return r.BankUnit.Update(ctx, bankUnit)
+}
+
+func (r *BankUnitRepo) DeleteAll(ctx context.Context) error {
+	defer r.invalidateAll()
+	return r.BankUnit.DeleteAll(ctx)
+}
+
+// Delete also drops the branches of the institution, which a cascading delete removes.
+func (r *BankUnitRepo) Delete(ctx context.Context, swiftCode model.SwiftCode, opts repo.DeleteOptions) error {
+	defer r.invalidate(swiftCode)
+	return r.BankUnit.Delete(ctx, swiftCode, opts)
+}
+
+func (r *BankUnitRepo) Restore(ctx context.Context, swiftCode model.SwiftCode) (*model.BankUnit, error) {
+	defer r.invalidate(swiftCode)
+	return r.BankUnit.Restore(ctx, swiftCode)
+}
+
+func (r *BankUnitRepo) ApplyChangeset(ctx context.Context, changes repo.Changeset) error {
+	swiftCodes := append(swiftCodesOf(changes.Create), swiftCodesOf(changes.Update)...)
+	defer r.invalidate(append(swiftCodes, changes.Delete...)...)
+	return r.BankUnit.ApplyChangeset(ctx, changes)
+}
+
+// lookup returns the cached value or, on a miss, the generation to pass to store.
+func (r *BankUnitRepo) lookup(key string) (any, uint64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.entries.get(key, time.Now())
+	if ok {
+		r.stats.Hits++
+	} else {
+		r.stats.Misses++
+	}
+	return v, r.generation, ok
+}
+
+func (r *BankUnitRepo) store(key string, value any, generation uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.generation != generation {
+		return
+	}
+	r.stats.Evictions += uint64(r.entries.put(key, value, time.Now()))
+}
+
+// invalidate drops the entries of every bank unit sharing the institution and
+// location (the base code) with one of the swift codes, so the branch list of a
+// headquarters goes together with its branches.
+func (r *BankUnitRepo) invalidate(swiftCodes ...model.SwiftCode) {
+	bases := map[string]bool{}
+	for _, swiftCode := range swiftCodes {
+		bases[swiftCode.BaseCode()] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	r.entries.removeFunc(func(key string) bool {
+		_, code, _ := strings.Cut(key, ":")
+		return bases[code[:8]]
+	})
+}
+
+func (r *BankUnitRepo) invalidateAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	r.entries.clear()
+}
+
+func swiftCodesOf(bankUnits []*model.BankUnit) []model.SwiftCode {
+	swiftCodes := make([]model.SwiftCode, len(bankUnits))
+	for i, bankUnit := range bankUnits {
+		swiftCodes[i] = bankUnit.SwiftCode
+	}
+	return swiftCodes
+}
+
+// cloneBankUnit copies the bank unit, so callers modifying what they read do not
+// change the cached entries.
+func cloneBankUnit(bankUnit *model.BankUnit) *model.BankUnit {
+	clone := *bankUnit
+	return &clone
+}
+
+func cloneBankUnits(bankUnits []*model.BankUnit) []*model.BankUnit {
+	clones := make([]*model.BankUnit, len(bankUnits))
+	for i, bankUnit := range bankUnits {
+		clones[i] = cloneBankUnit(bankUnit)
+	}
+	return clones
+}
@@ -0,0 +1,239 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/cache"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBankUnits serves bank units from a map and counts the reads reaching it.
+// Methods the tests do not use panic through the nil embedded interface.
+type fakeBankUnits struct {
+	repo.BankUnit
+	units map[model.SwiftCode]*model.BankUnit
+	reads int
+}
+
+func newFakeBankUnits(units ...*model.BankUnit) *fakeBankUnits {
+	f := &fakeBankUnits{units: map[model.SwiftCode]*model.BankUnit{}}
+	for _, bu := range units {
+		f.units[bu.SwiftCode] = bu
+	}
+	return f
+}
+
+func (f *fakeBankUnits) GetBySwiftCode(_ context.Context, swiftCode model.SwiftCode, _ repo.ReadOptions) (*model.BankUnit, error) {
+	f.reads++
+	bu, ok := f.units[swiftCode.Canonical()]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	clone := *bu
+	return &clone, nil
+}
+
+func (f *fakeBankUnits) GetBranches(_ context.Context, swiftCode model.SwiftCode, _ repo.ReadOptions) ([]*model.BankUnit, error) {
+	f.reads++
+	var branches []*model.BankUnit
+	for code, bu := range f.units {
+		if code.BaseCode() == swiftCode.BaseCode() && code != swiftCode {
+			clone := *bu
+			branches = append(branches, &clone)
+		}
+	}
+	return branches, nil
+}
+
+func (f *fakeBankUnits) Create(_ context.Context, bu *model.BankUnit) error {
+	f.units[bu.SwiftCode] = bu
+	return nil
+}
+
+func (f *fakeBankUnits) Update(_ context.Context, bu *model.BankUnit) error {
+	f.units[bu.SwiftCode] = bu
+	return nil
+}
+
+func (f *fakeBankUnits) Delete(_ context.Context, swiftCode model.SwiftCode, _ repo.DeleteOptions) error {
+	delete(f.units, swiftCode)
+	return nil
+}
+
+func (f *fakeBankUnits) DeleteAll(_ context.Context) error {
+	clear(f.units)
+	return nil
+}
+
+func Must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func TestBankUnitRepo(t *testing.T) {
+	ctx := context.Background()
+	hqCode := Must(model.NewSwiftCode("BPKOPLPWXXX"))
+	branchCode := Must(model.NewSwiftCode("BPKOPLPWCSD"))
+	otherCode := Must(model.NewSwiftCode("BEFNBGS1XXX"))
+
+	fixture := func() *fakeBankUnits {
+		return newFakeBankUnits(
+			Must(model.NewBankUnit("BPKOPLPWXXX", "PL", "POLAND", "WARSZAWA", "PKO BANK POLSKI S.A.", true)),
+			Must(model.NewBankUnit("BPKOPLPWCSD", "PL", "POLAND", "WARSZAWA", "PKO BANK POLSKI S.A.", false)),
+			Must(model.NewBankUnit("BEFNBGS1XXX", "BG", "BULGARIA", "SOFIA", "BENCHMARK FINANCE", true)),
+		)
+	}
+
+	t.Run("reads are cached", func(t *testing.T) {
+		fake := fixture()
+		r := cache.NewBankUnitRepo(fake, 10, time.Minute)
+
+		for range 3 {
+			hq, err := r.GetBySwiftCode(ctx, hqCode, repo.ReadOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, "PKO BANK POLSKI S.A.", hq.Name)
+			branches, err := r.GetBranches(ctx, hqCode, repo.ReadOptions{})
+			assert.NoError(t, err)
+			assert.Len(t, branches, 1)
+		}
+		assert.Equal(t, 2, fake.reads)
+
+		bic8, err := r.GetBySwiftCode(ctx, Must(model.NewSwiftCode("BPKOPLPW")), repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, hqCode, bic8.SwiftCode)
+		assert.Equal(t, 2, fake.reads)
+
+		assert.Equal(t, cache.Stats{Hits: 5, Misses: 2, Entries: 2}, r.Stats())
+	})
+
+	t.Run("cached entries cannot be modified by callers", func(t *testing.T) {
+		r := cache.NewBankUnitRepo(fixture(), 10, time.Minute)
+
+		hq, err := r.GetBySwiftCode(ctx, hqCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		hq.Name = "CHANGED"
+
+		hq, err = r.GetBySwiftCode(ctx, hqCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "PKO BANK POLSKI S.A.", hq.Name)
+	})
+
+	t.Run("other read options bypass the cache", func(t *testing.T) {
+		fake := fixture()
+		r := cache.NewBankUnitRepo(fake, 10, time.Minute)
+
+		for _, opts := range []repo.ReadOptions{{IncludeDeleted: true}, {AsOf: time.Now()}, {IncludeDeleted: true, AsOf: time.Now()}} {
+			_, err := r.GetBySwiftCode(ctx, hqCode, opts)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 3, fake.reads)
+		assert.Equal(t, 0, r.Stats().Entries)
+	})
+
+	t.Run("misses are not cached", func(t *testing.T) {
+		fake := fixture()
+		r := cache.NewBankUnitRepo(fake, 10, time.Minute)
+
+		for range 2 {
+			_, err := r.GetBySwiftCode(ctx, Must(model.NewSwiftCode("DEUTDEFFXXX")), repo.ReadOptions{})
+			assert.ErrorIs(t, err, repo.ErrNotFound)
+		}
+		assert.Equal(t, 2, fake.reads)
+	})
+
+	t.Run("writing a branch drops its headquarters branch list", func(t *testing.T) {
+		fake := fixture()
+		r := cache.NewBankUnitRepo(fake, 10, time.Minute)
+
+		_, err := r.GetBranches(ctx, hqCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		_, err = r.GetBySwiftCode(ctx, otherCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+
+		newBranch := Must(model.NewBankUnit("BPKOPLPWGDG", "PL", "POLAND", "GDYNIA", "PKO BANK POLSKI S.A.", false))
+		assert.NoError(t, r.Create(ctx, newBranch))
+
+		branches, err := r.GetBranches(ctx, hqCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, branches, 2)
+
+		assert.NoError(t, r.Delete(ctx, branchCode, repo.DeleteOptions{}))
+		branches, err = r.GetBranches(ctx, hqCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, branches, 1)
+
+		_, err = r.GetBySwiftCode(ctx, otherCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), r.Stats().Hits, "other institutions stay cached")
+	})
+
+	t.Run("updates are visible", func(t *testing.T) {
+		r := cache.NewBankUnitRepo(fixture(), 10, time.Minute)
+
+		hq, err := r.GetBySwiftCode(ctx, hqCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		hq.Name = "PKO BP"
+		assert.NoError(t, r.Update(ctx, hq))
+
+		hq, err = r.GetBySwiftCode(ctx, hqCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "PKO BP", hq.Name)
+	})
+
+	t.Run("delete all drops everything", func(t *testing.T) {
+		r := cache.NewBankUnitRepo(fixture(), 10, time.Minute)
+
+		_, err := r.GetBySwiftCode(ctx, otherCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.NoError(t, r.DeleteAll(ctx))
+
+		_, err = r.GetBySwiftCode(ctx, otherCode, repo.ReadOptions{})
+		assert.ErrorIs(t, err, repo.ErrNotFound)
+	})
+
+	t.Run("least recently used entries are evicted", func(t *testing.T) {
+		fake := fixture()
+		r := cache.NewBankUnitRepo(fake, 2, time.Minute)
+
+		for _, code := range []model.SwiftCode{hqCode, branchCode, hqCode, otherCode} {
+			_, err := r.GetBySwiftCode(ctx, code, repo.ReadOptions{})
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, cache.Stats{Hits: 1, Misses: 3, Evictions: 1, Entries: 2}, r.Stats())
+
+		_, err := r.GetBySwiftCode(ctx, hqCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		_, err = r.GetBySwiftCode(ctx, branchCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, fake.reads)
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		fake := fixture()
+		r := cache.NewBankUnitRepo(fake, 10, time.Millisecond)
+
+		_, err := r.GetBySwiftCode(ctx, hqCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+		_, err = r.GetBySwiftCode(ctx, hqCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, fake.reads)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		fake := fixture()
+		r := cache.NewBankUnitRepo(fake, 0, time.Minute)
+
+		for range 2 {
+			_, err := r.GetBySwiftCode(ctx, hqCode, repo.ReadOptions{})
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 2, fake.reads)
+	})
+}
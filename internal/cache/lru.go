@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+// lru is a size bounded map dropping the least recently used entries first. Entries
+// also expire after a fixed time. It is not safe for concurrent use.
+type lru struct {
+	capacity int
+	ttl      time.Duration
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type lruEntry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+func newLRU(capacity int, ttl time.Duration) *lru {
+	return &lru{capacity: capacity, ttl: ttl, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// get returns the value of a live entry and marks it as recently used.
+func (c *lru) get(key string, now time.Time) (any, bool) {
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*lruEntry)
+	if !now.Before(entry.expiresAt) {
+		c.removeElement(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// put stores the value and reports how many entries were evicted to make room for it.
+func (c *lru) put(key string, value any, now time.Time) int {
+	if c.capacity <= 0 {
+		return 0
+	}
+	if el, ok := c.entries[key]; ok {
+		el.Value = &lruEntry{key: key, value: value, expiresAt: now.Add(c.ttl)}
+		c.order.MoveToFront(el)
+		return 0
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: now.Add(c.ttl)})
+	evicted := 0
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+		evicted++
+	}
+	return evicted
+}
+
+// removeFunc drops every entry whose key matches.
+func (c *lru) removeFunc(match func(key string) bool) {
+	for key, el := range c.entries {
+		if match(key) {
+			c.removeElement(el)
+		}
+	}
+}
+
+func (c *lru) clear() {
+	c.order.Init()
+	clear(c.entries)
+}
+
+func (c *lru) len() int {
+	return c.order.Len()
+}
+
+func (c *lru) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*lruEntry).key)
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+type ServerConfig struct {
+	Host            string
+	Port            int
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	ShutdownTimeout time.Duration
+	RateLimit       RateLimitConfig
+	Cache           CacheConfig
+}
+
+// CacheConfig sizes the in-memory cache of bank unit lookups, a zero Size disables it.
+type CacheConfig struct {
+	Size int
+	TTL  time.Duration
+}
+
+// RateLimitConfig limits requests per caller, separately for reads and writes, and
+// per client address before the caller is authenticated. A zero rate disables the limit.
+type RateLimitConfig struct {
+	ReadsPerMinute   int
+	ReadBurst        int
+	WritesPerMinute  int
+	WriteBurst       int
+	AddressPerMinute int
+	AddressBurst     int
+	// TrustForwardedFor identifies anonymous callers by X-Forwarded-For instead of
+	// the connection address, for deployments behind a proxy.
+	TrustForwardedFor bool
+}
+
+func LoadServerConfig() ServerConfig {
+	return ServerConfig{
+		Host:            getEnvOr("SERVER_HOST", "0.0.0.0"),
+		Port:            getEnvIntOr("SERVER_PORT", 8080),
+		ReadTimeout:     getEnvDurationOr("SERVER_READ_TIMEOUT", 5*time.Second),
+		WriteTimeout:    getEnvDurationOr("SERVER_WRITE_TIMEOUT", 10*time.Second),
+		ShutdownTimeout: getEnvDurationOr("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+		RateLimit: RateLimitConfig{
+			ReadsPerMinute:    getEnvIntOr("RATE_LIMIT_READS_PER_MINUTE", 600),
+			ReadBurst:         getEnvIntOr("RATE_LIMIT_READ_BURST", 50),
+			WritesPerMinute:   getEnvIntOr("RATE_LIMIT_WRITES_PER_MINUTE", 60),
+			WriteBurst:        getEnvIntOr("RATE_LIMIT_WRITE_BURST", 10),
+			AddressPerMinute:  getEnvIntOr("RATE_LIMIT_ADDRESS_PER_MINUTE", 1200),
+			AddressBurst:      getEnvIntOr("RATE_LIMIT_ADDRESS_BURST", 100),
+			TrustForwardedFor: getEnvBoolOr("RATE_LIMIT_TRUST_FORWARDED_FOR", false),
+		},
+		Cache: CacheConfig{
+			Size: getEnvIntOr("CACHE_SIZE", 10000),
+			TTL:  getEnvDurationOr("CACHE_TTL", 10*time.Minute),
+		},
+	}
+}
+
+// Storage backends, see StorageConfig.
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// StorageConfig selects where the data is kept. The memory backend needs no database,
+// it starts from the initial data and loses every change when the server stops.
+type StorageConfig struct {
+	Backend string
+}
+
+func LoadStorageConfig() StorageConfig {
+	return StorageConfig{
+		Backend: getEnvOr("STORAGE_BACKEND", StoragePostgres),
+	}
+}
+
+func LoadDatabaseConnectionStr() string {
+	host := getEnvOr("DB_HOST", "localhost")
+	port := getEnvIntOr("DB_PORT", 5432)
+	name := getEnvOr("DB_NAME", "swiftcodes")
+	user := getEnvOr("DB_USER", "postgres")
+	password := getEnvOr("DB_PASSWORD", "postgres")
+
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s:%d/%s?sslmode=disable",
+		user, password, host, port, name,
+	)
+}
+
+type AuthConfig struct {
+	// APIKeys lists static API keys as comma separated subject:role:key entries.
+	APIKeys string
+	// JWKSFile is the JSON Web Key Set bearer tokens are verified against,
+	// bearer tokens are refused when it is empty.
+	JWKSFile    string
+	JWTIssuer   string
+	JWTAudience string
+	RoleClaim   string
+	// AnonymousRole is granted to requests without credentials, "none" requires
+	// credentials for every endpoint.
+	AnonymousRole string
+	// KeyCacheTTL is how long API keys issued through the API are cached, revoked
+	// keys stay usable on other instances for up to this long.
+	KeyCacheTTL time.Duration
+}
+
+func LoadAuthConfig() AuthConfig {
+	return AuthConfig{
+		APIKeys:       os.Getenv("AUTH_API_KEYS"),
+		JWKSFile:      os.Getenv("AUTH_JWKS_FILE"),
+		JWTIssuer:     os.Getenv("AUTH_JWT_ISSUER"),
+		JWTAudience:   os.Getenv("AUTH_JWT_AUDIENCE"),
+		RoleClaim:     getEnvOr("AUTH_ROLE_CLAIM", "role"),
+		AnonymousRole: getEnvOr("AUTH_ANONYMOUS_ROLE", "reader"),
+		KeyCacheTTL:   getEnvDurationOr("AUTH_KEY_CACHE_TTL", time.Minute),
+	}
+}
+
+func getEnvOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvIntOr(key string, fallback int) int {
+	str := os.Getenv(key)
+	if str == "" {
+		return fallback
+	}
+	v, err := strconv.Atoi(str)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+func getEnvBoolOr(key string, fallback bool) bool {
+	str := os.Getenv(key)
+	if str == "" {
+		return fallback
+	}
+	v, err := strconv.ParseBool(str)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+func getEnvDurationOr(key string, fallback time.Duration) time.Duration {
+	str := os.Getenv(key)
+	if str == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
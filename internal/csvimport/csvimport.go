@@ -2,7 +2,10 @@ package csvimport
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"iter"
 	"log"
 
 	"github.com/pkarmon/swiftcodes/internal/csvmapper"
@@ -11,9 +14,7 @@ import (
 )
 
 func Countries(ctx context.Context, src io.Reader, r repo.Country) error {
-	mapper := csvmapper.New(src, []string{"Name", "Code"}, mapCSVRecordToCountry)
-
-	countries, err := mapper.MapAll()
+	countries, err := newCountryMapper(src).MapAll()
 	if err != nil {
 		return err
 	}
@@ -29,6 +30,10 @@ func Countries(ctx context.Context, src io.Reader, r repo.Country) error {
 	return nil
 }
 
+func newCountryMapper(src io.Reader) *csvmapper.Mapper[model.Country] {
+	return csvmapper.New(src, []string{"Name", "Code"}, mapCSVRecordToCountry)
+}
+
 func mapCSVRecordToCountry(record []string) (model.Country, error) {
 	country, err := model.NewCountry(record[1], record[0])
 	if err != nil {
@@ -37,23 +42,71 @@ func mapCSVRecordToCountry(record []string) (model.Country, error) {
 	return country, nil
 }
 
+// BankUnits streams bank units from src into the repository, so memory use does not grow
+// with the size of the file. Nothing is imported when any row is invalid.
 func BankUnits(ctx context.Context, src io.Reader, r repo.BankUnit) error {
-	mapper := csvmapper.New(src, []string{"SWIFT CODE", "COUNTRY ISO2 CODE", "COUNTRY NAME", "NAME", "ADDRESS"}, mapCSVRecordToBankUnit)
-
-	bankUnits, err := mapper.MapAll()
+	imported, err := r.BulkCreateFrom(ctx, newBankUnitMapper(src).All())
 	if err != nil {
 		return err
 	}
 
-	log.Printf("Successfully loaded %d bank units", len(bankUnits))
+	log.Printf("Successfully imported %d bank units", imported)
 
-	if err := r.BulkCreate(ctx, bankUnits); err != nil {
-		return err
+	return nil
+}
+
+// Report summarizes a lenient import.
+type Report struct {
+	Imported int
+	Rejected []*csvmapper.RowError
+}
+
+// BankUnitsLenient imports every valid bank unit from src. Invalid rows are skipped, logged
+// and returned in the report together with their line number, raw record and validation error.
+func BankUnitsLenient(ctx context.Context, src io.Reader, r repo.BankUnit) (*Report, error) {
+	report := &Report{Rejected: make([]*csvmapper.RowError, 0)}
+	valid := skipRejected(newBankUnitMapper(src).All(), func(rowErr *csvmapper.RowError) {
+		log.Printf("Rejected bank unit: %v", rowErr)
+		report.Rejected = append(report.Rejected, rowErr)
+	})
+
+	imported, err := r.BulkCreateFrom(ctx, valid)
+	if err != nil {
+		return nil, err
 	}
+	report.Imported = imported
 
-	log.Println("Bank units imported successfully")
+	log.Printf("Successfully imported %d bank units, rejected %d", report.Imported, len(report.Rejected))
 
-	return nil
+	return report, nil
+}
+
+// skipRejected passes on everything but row errors, which are handed to reject.
+func skipRejected[T any](seq iter.Seq2[T, error], reject func(*csvmapper.RowError)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for element, err := range seq {
+			var rowErr *csvmapper.RowError
+			if errors.As(err, &rowErr) {
+				reject(rowErr)
+				continue
+			}
+			if !yield(element, err) {
+				return
+			}
+		}
+	}
+}
+
+// ReadBankUnits returns an iterator over the bank units in a CSV file with the SWIFT directory
+// columns. Invalid rows are yielded as *csvmapper.RowError, see csvmapper.Mapper.All.
+func ReadBankUnits(src io.Reader) iter.Seq2[*model.BankUnit, error] {
+	return newBankUnitMapper(src).All()
+}
+
+func newBankUnitMapper(src io.Reader) *csvmapper.Mapper[*model.BankUnit] {
+	return csvmapper.New(src, []string{
+		"SWIFT CODE", "COUNTRY ISO2 CODE", "COUNTRY NAME", "NAME", "ADDRESS", "CODE TYPE", "TOWN NAME", "TIME ZONE",
+	}, mapCSVRecordToBankUnit)
 }
 
 func mapCSVRecordToBankUnit(record []string) (*model.BankUnit, error) {
@@ -76,5 +129,13 @@ func mapCSVRecordToBankUnit(record []string) (*model.BankUnit, error) {
 		return nil, err
 	}
 
+	if err := model.ValidateTimeZone(record[7]); err != nil {
+		return nil, fmt.Errorf("%w: %q", err, record[7])
+	}
+
+	bankUnit.CodeType = record[5]
+	bankUnit.TownName = record[6]
+	bankUnit.TimeZone = record[7]
+
 	return bankUnit, nil
 }
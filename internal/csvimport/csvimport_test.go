@@ -2,34 +2,29 @@ package csvimport_test
 
 import (
 	"context"
-	"log"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkarmon/swiftcodes/internal/csvimport"
+	"github.com/pkarmon/swiftcodes/internal/memory"
 	"github.com/pkarmon/swiftcodes/internal/model"
-	"github.com/pkarmon/swiftcodes/internal/postgres"
 	"github.com/pkarmon/swiftcodes/internal/repo"
 	"github.com/stretchr/testify/assert"
 )
 
 var (
-	db           postgres.DB
-	bankUnitRepo repo.BankUnit
-	countryRepo  repo.Country
+	store         *memory.Store
+	bankUnitRepo  repo.BankUnit
+	countryRepo   repo.Country
+	importRunRepo repo.ImportRun
 )
 
 func TestMain(m *testing.M) {
-	ctx := context.Background()
-	createdDb, cleanup, err := postgres.ConfigureTestDB(ctx)
-	if err != nil {
-		log.Fatalf("could not seutp test db: %s", err)
-	}
-	defer cleanup()
-
-	db = createdDb
-	bankUnitRepo = postgres.NewBankUnitRepo(db)
-	countryRepo = postgres.NewCountryRepo(db)
+	store = memory.NewStore()
+	bankUnitRepo = memory.NewBankUnitRepo(store)
+	countryRepo = memory.NewCountryRepo(store)
+	importRunRepo = memory.NewImportRunRepo(store)
 
 	m.Run()
 }
@@ -38,10 +33,7 @@ func TestImportCountries(t *testing.T) {
 	ctx := context.Background()
 	clearDB := func(t *testing.T) func() {
 		return func() {
-			err := db.DropSchema(ctx)
-			assert.NoError(t, err)
-			err = db.SetupSchema(ctx)
-			assert.NoError(t, err)
+			store.Clear()
 		}
 	}
 
@@ -106,7 +98,7 @@ PL,HYVEPLP2XXX,BIC11,PEKAO BANK HIPOTECZNY SA,"RENAISSANCE TOWER UL. SKIERNIEWIC
 		err := csvimport.BankUnits(ctx, strings.NewReader(csvData), bankUnitRepo)
 
 		assert.Error(t, err)
-		bankUnits, err := bankUnitRepo.GetAll(ctx)
+		bankUnits, err := bankUnitRepo.GetAll(ctx, repo.ReadOptions{})
 		assert.NoError(t, err)
 		assert.Len(t, bankUnits, 0)
 	})
@@ -121,19 +113,249 @@ PL,HYVEPLP2XXX,BIC11,PEKAO BANK HIPOTECZNY SA,"RENAISSANCE TOWER UL. SKIERNIEWIC
 		err := csvimport.BankUnits(ctx, strings.NewReader(csvData), bankUnitRepo)
 
 		assert.NoError(t, err)
-		bankUnits, err := bankUnitRepo.GetAll(ctx)
+		bankUnits, err := bankUnitRepo.GetAll(ctx, repo.ReadOptions{})
 		assert.NoError(t, err)
 		assert.Len(t, bankUnits, 2)
 
 		milienium := mustNewBankUnit(t, "BIGBPLPWCUS", "PL", "POLAND", "BANK MILLENNIUM S.A.", "HARMONY CENTER UL. STANISLAWA ZARYNA 2A WARSZAWA, MAZOWIECKIE, 02-593", false)
+		withDirectoryDetails(milienium, "BIC11", "WARSZAWA", "Europe/Warsaw")
 		pekao := mustNewBankUnit(t, "HYVEPLP2XXX", "PL", "POLAND", "PEKAO BANK HIPOTECZNY SA", "RENAISSANCE TOWER UL. SKIERNIEWICKA 10A WARSZAWA, MAZOWIECKIE, 01-230", true)
+		withDirectoryDetails(pekao, "BIC11", "WARSZAWA", "Europe/Warsaw")
 
 		bankUnitsValues := []model.BankUnit{*bankUnits[0], *bankUnits[1]}
+		for i := range bankUnitsValues {
+			// the version start is set on import, it is checked by the repository tests
+			assert.False(t, bankUnitsValues[i].ValidFrom.IsZero())
+			bankUnitsValues[i].ValidFrom = time.Time{}
+		}
 
 		assert.Contains(t, bankUnitsValues, *milienium)
 		assert.Contains(t, bankUnitsValues, *pekao)
 	})
 
+	t.Run("BIC8 codes are imported as headquarters", func(t *testing.T) {
+		t.Cleanup(clearDB(t))
+
+		csvData := `COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE
+PL,HYVEPLP2,BIC8,PEKAO BANK HIPOTECZNY SA,"RENAISSANCE TOWER UL. SKIERNIEWICKA 10A WARSZAWA, MAZOWIECKIE, 01-230",WARSZAWA,POLAND,Europe/Warsaw`
+
+		err := csvimport.BankUnits(ctx, strings.NewReader(csvData), bankUnitRepo)
+
+		assert.NoError(t, err)
+		bankUnits, err := bankUnitRepo.GetAll(ctx, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, bankUnits, 1)
+		assert.Equal(t, "HYVEPLP2XXX", bankUnits[0].SwiftCode.String())
+		assert.True(t, bankUnits[0].IsHeadquarter)
+	})
+
+	t.Run("lenient import skips invalid rows", func(t *testing.T) {
+		t.Cleanup(clearDB(t))
+
+		csvData := `COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE
+PL,BIGBPLPWCUS,BIC11,BANK MILLENNIUM S.A.,"HARMONY CENTER UL. STANISLAWA ZARYNA 2A WARSZAWA, MAZOWIECKIE, 02-593",WARSZAWA,POLAND,Europe/Warsaw
+PL,12$%PL!!XXX,BIC11,BROKEN BANK,"NOWHERE",WARSZAWA,POLAND,Europe/Warsaw
+PL,HYVEPLP2XXX,BIC11,PEKAO BANK HIPOTECZNY SA,"RENAISSANCE TOWER UL. SKIERNIEWICKA 10A WARSZAWA, MAZOWIECKIE, 01-230",WARSZAWA,POLAND,Europe/Warszawa`
+
+		report, err := csvimport.BankUnitsLenient(ctx, strings.NewReader(csvData), bankUnitRepo)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Imported)
+		assert.Len(t, report.Rejected, 2)
+		assert.Equal(t, 2, report.Rejected[0].Line)
+		assert.Equal(t, "12$%PL!!XXX", report.Rejected[0].Record[1])
+		assert.ErrorContains(t, report.Rejected[0], model.ErrInvalidInstitutionCode.Error())
+		assert.Equal(t, 3, report.Rejected[1].Line)
+		assert.ErrorContains(t, report.Rejected[1], model.ErrInvalidTimeZone.Error())
+
+		bankUnits, err := bankUnitRepo.GetAll(ctx, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, bankUnits, 1)
+		assert.Equal(t, "BIGBPLPWCUS", bankUnits[0].SwiftCode.String())
+	})
+
+	t.Run("invalid time zone", func(t *testing.T) {
+		t.Cleanup(clearDB(t))
+
+		csvData := `COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE
+PL,HYVEPLP2XXX,BIC11,PEKAO BANK HIPOTECZNY SA,"RENAISSANCE TOWER UL. SKIERNIEWICKA 10A WARSZAWA, MAZOWIECKIE, 01-230",WARSZAWA,POLAND,Europe/Warszawa`
+
+		err := csvimport.BankUnits(ctx, strings.NewReader(csvData), bankUnitRepo)
+
+		assert.ErrorContains(t, err, model.ErrInvalidTimeZone.Error())
+		bankUnits, err := bankUnitRepo.GetAll(ctx, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, bankUnits, 0)
+	})
+
+}
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+	resetDB := func() {
+		store.Clear()
+	}
+	resetDB()
+	t.Cleanup(resetDB)
+
+	countriesV1 := `Name,Code
+Poland,PL
+Germany,DE`
+	countriesV2 := `Name,Code
+Poland,PL
+Federal Republic of Germany,DE
+Bulgaria,BG`
+
+	t.Run("countries are imported once per file", func(t *testing.T) {
+		err := csvimport.SeedCountries(ctx, "countries.csv", strings.NewReader(countriesV1), countryRepo, importRunRepo)
+		assert.NoError(t, err)
+
+		first, err := importRunRepo.GetLatest(ctx, "countries.csv")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, first.RowsRead)
+		assert.Equal(t, 2, first.RowsChanged)
+		assert.Len(t, first.FileHash, 64)
+
+		err = csvimport.SeedCountries(ctx, "countries.csv", strings.NewReader(countriesV1), countryRepo, importRunRepo)
+		assert.NoError(t, err)
+
+		second, err := importRunRepo.GetLatest(ctx, "countries.csv")
+		assert.NoError(t, err)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("changed countries file is upserted", func(t *testing.T) {
+		err := csvimport.SeedCountries(ctx, "countries.csv", strings.NewReader(countriesV2), countryRepo, importRunRepo)
+		assert.NoError(t, err)
+
+		run, err := importRunRepo.GetLatest(ctx, "countries.csv")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, run.RowsRead)
+		assert.Equal(t, 2, run.RowsChanged)
+
+		countries, err := countryRepo.GetAll(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, countries, 3)
+		assert.Contains(t, countries, mustNewCountry(t, "DE", "Federal Republic of Germany"))
+	})
+
+	t.Run("bank units are upserted by swift code", func(t *testing.T) {
+		bankUnitsV1 := `COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE
+PL,BIGBPLPWCUS,BIC11,BANK MILLENNIUM S.A.,"HARMONY CENTER UL. STANISLAWA ZARYNA 2A WARSZAWA, MAZOWIECKIE, 02-593",WARSZAWA,POLAND,Europe/Warsaw
+PL,HYVEPLP2XXX,BIC11,PEKAO BANK HIPOTECZNY SA,"RENAISSANCE TOWER UL. SKIERNIEWICKA 10A WARSZAWA, MAZOWIECKIE, 01-230",WARSZAWA,POLAND,Europe/Warsaw`
+		bankUnitsV2 := `COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE
+PL,BIGBPLPWCUS,BIC11,BANK MILLENNIUM S.A.,"HARMONY CENTER UL. STANISLAWA ZARYNA 2A WARSZAWA, MAZOWIECKIE, 02-593",WARSZAWA,POLAND,Europe/Warsaw
+PL,HYVEPLP2XXX,BIC11,PEKAO BANK HIPOTECZNY SA,"UL. ZUPNICZA 17 WARSZAWA, MAZOWIECKIE, 03-821",WARSZAWA,POLAND,Europe/Warsaw
+DE,DEUTDEFFXXX,BIC11,DEUTSCHE BANK AG,"TAUNUSANLAGE 12 FRANKFURT AM MAIN, HESSEN, 60325",FRANKFURT AM MAIN,FEDERAL REPUBLIC OF GERMANY,Europe/Berlin`
+
+		err := csvimport.SeedBankUnits(ctx, "swiftcodes.csv", strings.NewReader(bankUnitsV1), bankUnitRepo, importRunRepo)
+		assert.NoError(t, err)
+		err = csvimport.SeedBankUnits(ctx, "swiftcodes.csv", strings.NewReader(bankUnitsV2), bankUnitRepo, importRunRepo)
+		assert.NoError(t, err)
+
+		run, err := importRunRepo.GetLatest(ctx, "swiftcodes.csv")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, run.RowsRead)
+		assert.Equal(t, 2, run.RowsChanged)
+
+		bankUnits, err := bankUnitRepo.GetAll(ctx, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, bankUnits, 3)
+
+		pekao, err := bankUnitRepo.GetBySwiftCode(ctx, mustNewSwiftCode(t, "HYVEPLP2XXX"), repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "UL. ZUPNICZA 17 WARSZAWA, MAZOWIECKIE, 03-821", pekao.Address)
+	})
+}
+
+func TestDiffBankUnits(t *testing.T) {
+	ctx := context.Background()
+	resetDB := func() {
+		store.Clear()
+	}
+	resetDB()
+	t.Cleanup(resetDB)
+
+	err := csvimport.Countries(ctx, strings.NewReader(`Name,Code
+Poland,PL`), countryRepo)
+	assert.NoError(t, err)
+
+	current := `COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE
+PL,BIGBPLPWCUS,BIC11,BANK MILLENNIUM S.A.,"HARMONY CENTER UL. STANISLAWA ZARYNA 2A WARSZAWA, MAZOWIECKIE, 02-593",WARSZAWA,POLAND,Europe/Warsaw
+PL,HYVEPLP2XXX,BIC11,PEKAO BANK HIPOTECZNY SA,"RENAISSANCE TOWER UL. SKIERNIEWICKA 10A WARSZAWA, MAZOWIECKIE, 01-230",WARSZAWA,POLAND,Europe/Warsaw
+PL,BPKOPLPWXXX,BIC11,PKO BANK POLSKI S.A.,"UL. PULAWSKA 15  WARSZAWA, MAZOWIECKIE, 02-515",WARSZAWA,POLAND,Europe/Warsaw`
+	incoming := `COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE
+PL,BIGBPLPWCUS,BIC11,BANK MILLENNIUM S.A.,"HARMONY CENTER UL. STANISLAWA ZARYNA 2A WARSZAWA, MAZOWIECKIE, 02-593",WARSZAWA,POLAND,Europe/Warsaw
+PL,HYVEPLP2XXX,BIC11,PEKAO BANK HIPOTECZNY,"UL. ZUPNICZA 17 WARSZAWA, MAZOWIECKIE, 03-821",WARSZAWA,POLAND,Europe/Warsaw
+PL,BREXPLPWXXX,BIC11,MBANK S.A.,"UL. PROSTA 18  WARSZAWA, MAZOWIECKIE, 00-850",WARSZAWA,POLAND,Europe/Warsaw`
+
+	assert.NoError(t, csvimport.BankUnits(ctx, strings.NewReader(current), bankUnitRepo))
+
+	t.Run("dry run reports changes without applying them", func(t *testing.T) {
+		report, err := csvimport.DiffBankUnits(ctx, strings.NewReader(incoming), bankUnitRepo, csvimport.DiffOptions{DryRun: true})
+		assert.NoError(t, err)
+
+		assert.Len(t, report.Added, 1)
+		assert.Equal(t, "BREXPLPWXXX", report.Added[0].SwiftCode.String())
+		assert.Len(t, report.Removed, 1)
+		assert.Equal(t, "BPKOPLPWXXX", report.Removed[0].SwiftCode.String())
+		assert.Len(t, report.Modified, 1)
+		assert.Equal(t, "HYVEPLP2XXX", report.Modified[0].After.SwiftCode.String())
+		assert.Equal(t, []string{csvimport.FieldName, csvimport.FieldAddress}, report.Modified[0].Fields)
+		assert.Equal(t, 1, report.Unchanged)
+
+		_, err = bankUnitRepo.GetBySwiftCode(ctx, mustNewSwiftCode(t, "BPKOPLPWXXX"), repo.ReadOptions{})
+		assert.NoError(t, err)
+		_, err = bankUnitRepo.GetBySwiftCode(ctx, mustNewSwiftCode(t, "BREXPLPWXXX"), repo.ReadOptions{})
+		assert.ErrorIs(t, err, repo.ErrNotFound)
+	})
+
+	t.Run("changes are applied", func(t *testing.T) {
+		report, err := csvimport.DiffBankUnits(ctx, strings.NewReader(incoming), bankUnitRepo, csvimport.DiffOptions{})
+		assert.NoError(t, err)
+		assert.True(t, report.HasChanges())
+
+		bankUnits, err := bankUnitRepo.GetAll(ctx, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, bankUnits, 3)
+
+		_, err = bankUnitRepo.GetBySwiftCode(ctx, mustNewSwiftCode(t, "BPKOPLPWXXX"), repo.ReadOptions{})
+		assert.ErrorIs(t, err, repo.ErrNotFound)
+		pekao, err := bankUnitRepo.GetBySwiftCode(ctx, mustNewSwiftCode(t, "HYVEPLP2XXX"), repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "PEKAO BANK HIPOTECZNY", pekao.Name)
+		assert.Equal(t, "UL. ZUPNICZA 17 WARSZAWA, MAZOWIECKIE, 03-821", pekao.Address)
+
+		report, err = csvimport.DiffBankUnits(ctx, strings.NewReader(incoming), bankUnitRepo, csvimport.DiffOptions{})
+		assert.NoError(t, err)
+		assert.False(t, report.HasChanges())
+		assert.Equal(t, 3, report.Unchanged)
+	})
+
+	t.Run("town and time zone changes are applied", func(t *testing.T) {
+		moved := `COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE
+PL,BIGBPLPWCUS,BIC11,BANK MILLENNIUM S.A.,"HARMONY CENTER UL. STANISLAWA ZARYNA 2A WARSZAWA, MAZOWIECKIE, 02-593",WARSAW,POLAND,Europe/Berlin
+PL,HYVEPLP2XXX,BIC11,PEKAO BANK HIPOTECZNY,"UL. ZUPNICZA 17 WARSZAWA, MAZOWIECKIE, 03-821",WARSZAWA,POLAND,Europe/Warsaw
+PL,BREXPLPWXXX,BIC11,MBANK S.A.,"UL. PROSTA 18  WARSZAWA, MAZOWIECKIE, 00-850",WARSZAWA,POLAND,Europe/Warsaw`
+
+		report, err := csvimport.DiffBankUnits(ctx, strings.NewReader(moved), bankUnitRepo, csvimport.DiffOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, report.Modified, 1)
+		assert.Equal(t, "BIGBPLPWCUS", report.Modified[0].After.SwiftCode.String())
+		assert.Equal(t, []string{csvimport.FieldTownName, csvimport.FieldTimeZone}, report.Modified[0].Fields)
+		assert.Equal(t, 2, report.Unchanged)
+
+		millennium, err := bankUnitRepo.GetBySwiftCode(ctx, mustNewSwiftCode(t, "BIGBPLPWCUS"), repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "WARSAW", millennium.TownName)
+		assert.Equal(t, "Europe/Berlin", millennium.TimeZone)
+	})
+}
+
+func mustNewSwiftCode(t *testing.T, code string) model.SwiftCode {
+	swiftCode, err := model.NewSwiftCode(code)
+	assert.NoError(t, err)
+	return swiftCode
 }
 
 func mustNewCountry(t *testing.T, code, name string) model.Country {
@@ -147,3 +369,9 @@ func mustNewBankUnit(t *testing.T, swiftCode, countryISO2, countryName, bankName
 	assert.NoError(t, err)
 	return bankUnit
 }
+
+func withDirectoryDetails(bankUnit *model.BankUnit, codeType, townName, timeZone string) {
+	bankUnit.CodeType = codeType
+	bankUnit.TownName = townName
+	bankUnit.TimeZone = timeZone
+}
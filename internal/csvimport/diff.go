@@ -0,0 +1,167 @@
+package csvimport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+// Fields compared when looking for modified bank units.
+const (
+	FieldName          = "name"
+	FieldAddress       = "address"
+	FieldIsHeadquarter = "isHeadquarter"
+	FieldCodeType      = "codeType"
+	FieldTownName      = "townName"
+	FieldTimeZone      = "timeZone"
+)
+
+type Modification struct {
+	Before *model.BankUnit
+	After  *model.BankUnit
+	// Fields lists the names of the changed fields.
+	Fields []string
+}
+
+// ChangeReport describes how an incoming directory differs from the stored bank units.
+// All lists are sorted by swift code.
+type ChangeReport struct {
+	Added     []*model.BankUnit
+	Removed   []*model.BankUnit
+	Modified  []Modification
+	Unchanged int
+}
+
+func (r *ChangeReport) HasChanges() bool {
+	return len(r.Added) > 0 || len(r.Removed) > 0 || len(r.Modified) > 0
+}
+
+func (r *ChangeReport) Changeset() repo.Changeset {
+	changes := repo.Changeset{
+		Create: r.Added,
+		Update: make([]*model.BankUnit, len(r.Modified)),
+		Delete: make([]model.SwiftCode, len(r.Removed)),
+	}
+	for i, m := range r.Modified {
+		changes.Update[i] = m.After
+	}
+	for i, bu := range r.Removed {
+		changes.Delete[i] = bu.SwiftCode
+	}
+	return changes
+}
+
+type DiffOptions struct {
+	// DryRun only computes the report without changing the database.
+	DryRun bool
+}
+
+// DiffBankUnits compares the directory in src with the current bank units. Bank units missing
+// from src are reported as removed. Unless opts.DryRun is set, the changes are applied
+// in a single transaction.
+func DiffBankUnits(ctx context.Context, src io.Reader, r repo.BankUnit, opts DiffOptions) (*ChangeReport, error) {
+	incoming, err := newBankUnitMapper(src).MapAll()
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := r.GetAll(ctx, repo.ReadOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := compareBankUnits(current, incoming)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Directory diff: %d added, %d removed, %d modified, %d unchanged",
+		len(report.Added), len(report.Removed), len(report.Modified), report.Unchanged)
+
+	if opts.DryRun || !report.HasChanges() {
+		return report, nil
+	}
+
+	if err := r.ApplyChangeset(ctx, report.Changeset()); err != nil {
+		return nil, err
+	}
+
+	log.Println("Directory changes applied successfully")
+
+	return report, nil
+}
+
+func compareBankUnits(current, incoming []*model.BankUnit) (*ChangeReport, error) {
+	currentByCode := make(map[string]*model.BankUnit, len(current))
+	for _, bu := range current {
+		currentByCode[bu.SwiftCode.String()] = bu
+	}
+
+	report := &ChangeReport{}
+	seen := make(map[string]bool, len(incoming))
+	for _, bu := range incoming {
+		code := bu.SwiftCode.String()
+		if seen[code] {
+			return nil, fmt.Errorf("swift code %s appears more than once", code)
+		}
+		seen[code] = true
+
+		before, ok := currentByCode[code]
+		if !ok {
+			report.Added = append(report.Added, bu)
+			continue
+		}
+
+		if fields := changedFields(before, bu); len(fields) > 0 {
+			report.Modified = append(report.Modified, Modification{Before: before, After: bu, Fields: fields})
+		} else {
+			report.Unchanged++
+		}
+	}
+
+	for _, bu := range current {
+		if !seen[bu.SwiftCode.String()] {
+			report.Removed = append(report.Removed, bu)
+		}
+	}
+
+	sortBySwiftCode(report.Added)
+	sortBySwiftCode(report.Removed)
+	sort.Slice(report.Modified, func(i, j int) bool {
+		return report.Modified[i].After.SwiftCode.String() < report.Modified[j].After.SwiftCode.String()
+	})
+
+	return report, nil
+}
+
+func changedFields(before, after *model.BankUnit) []string {
+	var fields []string
+	if before.Name != after.Name {
+		fields = append(fields, FieldName)
+	}
+	if before.Address != after.Address {
+		fields = append(fields, FieldAddress)
+	}
+	if before.IsHeadquarter != after.IsHeadquarter {
+		fields = append(fields, FieldIsHeadquarter)
+	}
+	if before.CodeType != after.CodeType {
+		fields = append(fields, FieldCodeType)
+	}
+	if before.TownName != after.TownName {
+		fields = append(fields, FieldTownName)
+	}
+	if before.TimeZone != after.TimeZone {
+		fields = append(fields, FieldTimeZone)
+	}
+	return fields
+}
+
+func sortBySwiftCode(units []*model.BankUnit) {
+	sort.Slice(units, func(i, j int) bool { return units[i].SwiftCode.String() < units[j].SwiftCode.String() })
+}
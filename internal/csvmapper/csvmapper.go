@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 )
 
 var (
@@ -37,38 +38,117 @@ func New[T any](reader io.Reader, columns []string, recordMapper func([]string)
 	}
 }
 
-func (r *Mapper[T]) MapAll() ([]T, error) {
-	if err := r.processHeader(); err != nil {
-		return nil, err
-	}
+// RowError describes a record that could not be mapped.
+type RowError struct {
+	// Line is the number of the record, the header is not counted.
+	Line int
+	// Record holds the raw fields, it is nil when the line could not be parsed as CSV.
+	Record []string
+	Err    error
+}
 
-	elements := make([]T, 0)
-	for lineIdx := 1; ; lineIdx++ {
-		record, err := r.csvReader.Read()
-		if err == io.EOF {
-			break
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// All returns an iterator over the mapped records, reading the input one record at a time.
+// Records that cannot be mapped are yielded as *RowError and the iteration continues,
+// so the consumer decides whether to stop or skip them. Any other error, including
+// a missing or mismatched header, is yielded once and ends the iteration.
+// The iterator can be ranged over only once.
+func (r *Mapper[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if err := r.processHeader(); err != nil {
+			yield(zero, err)
+			return
 		}
-		if errors.Is(err, csv.ErrFieldCount) {
-			return nil, fmt.Errorf("line %d: %w", lineIdx, ErrUnexpectedFieldCount)
+
+		for lineIdx := 1; ; lineIdx++ {
+			element, err := r.mapNext(lineIdx)
+			if err == io.EOF {
+				return
+			}
+			var rowErr *RowError
+			if err != nil && !errors.As(err, &rowErr) {
+				yield(zero, err)
+				return
+			}
+			if !yield(element, err) {
+				return
+			}
 		}
+	}
+}
+
+// MapAll maps every record, failing on the first one that cannot be mapped.
+func (r *Mapper[T]) MapAll() ([]T, error) {
+	elements := make([]T, 0)
+	for element, err := range r.All() {
 		if err != nil {
 			return nil, err
 		}
+		elements = append(elements, element)
+	}
 
-		convertedRecord := make([]string, 0, len(r.expectedColumns))
-		for _, col := range r.expectedColumnsOrdered {
-			convertedRecord = append(convertedRecord, record[r.columnNameToIndex[col]])
-		}
+	return elements, nil
+}
 
-		element, err := r.recordMapper(convertedRecord)
+// MapAllLenient maps every valid record and collects the rejected ones instead of failing.
+// An error is returned only when the file as a whole cannot be read.
+func (r *Mapper[T]) MapAllLenient() ([]T, []*RowError, error) {
+	elements := make([]T, 0)
+	rejected := make([]*RowError, 0)
+	for element, err := range r.All() {
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			rejected = append(rejected, rowErr)
+			continue
+		}
 		if err != nil {
-			return nil, fmt.Errorf("line %d: %w:  %v", lineIdx, ErrMapperError, err)
+			return nil, nil, err
 		}
-
 		elements = append(elements, element)
 	}
 
-	return elements, nil
+	return elements, rejected, nil
+}
+
+// mapNext reads and maps the next record. It returns io.EOF at the end of the input
+// and a *RowError when only this record is invalid.
+func (r *Mapper[T]) mapNext(lineIdx int) (T, error) {
+	var zero T
+
+	record, err := r.csvReader.Read()
+	if err == io.EOF {
+		return zero, io.EOF
+	}
+	if errors.Is(err, csv.ErrFieldCount) {
+		return zero, &RowError{Line: lineIdx, Record: record, Err: ErrUnexpectedFieldCount}
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return zero, &RowError{Line: lineIdx, Err: parseErr.Err}
+	}
+	if err != nil {
+		return zero, err
+	}
+
+	convertedRecord := make([]string, 0, len(r.expectedColumns))
+	for _, col := range r.expectedColumnsOrdered {
+		convertedRecord = append(convertedRecord, record[r.columnNameToIndex[col]])
+	}
+
+	element, err := r.recordMapper(convertedRecord)
+	if err != nil {
+		return zero, &RowError{Line: lineIdx, Record: record, Err: fmt.Errorf("%w:  %v", ErrMapperError, err)}
+	}
+
+	return element, nil
 }
 
 func (r *Mapper[T]) processHeader() error {
@@ -1,6 +1,7 @@
 package csvmapper
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -102,3 +103,129 @@ Jane,30,ignored`
 		assert.Equal(t, 25, result[0].Age)
 	})
 }
+
+func TestMapperLenient(t *testing.T) {
+	t.Run("collects rejected rows", func(t *testing.T) {
+		csv := `Name,Age
+John,25
+Jane,unknown
+Bob
+Alice,30`
+		m := New(strings.NewReader(csv),
+			[]string{"Name", "Age"},
+			testPersonMapper)
+
+		result, rejected, err := m.MapAllLenient()
+		assert.NoError(t, err)
+		assert.Equal(t, []testPerson{{Name: "John", Age: 25}, {Name: "Alice", Age: 30}}, result)
+
+		assert.Len(t, rejected, 2)
+		assert.Equal(t, 2, rejected[0].Line)
+		assert.Equal(t, []string{"Jane", "unknown"}, rejected[0].Record)
+		assert.ErrorIs(t, rejected[0], ErrMapperError)
+		assert.Equal(t, 3, rejected[1].Line)
+		assert.Equal(t, []string{"Bob"}, rejected[1].Record)
+		assert.ErrorIs(t, rejected[1], ErrUnexpectedFieldCount)
+	})
+
+	t.Run("malformed csv line", func(t *testing.T) {
+		csv := `Name,Age
+Jo"hn,25
+Jane,30`
+		m := New(strings.NewReader(csv),
+			[]string{"Name", "Age"},
+			testPersonMapper)
+
+		result, rejected, err := m.MapAllLenient()
+		assert.NoError(t, err)
+		assert.Equal(t, []testPerson{{Name: "Jane", Age: 30}}, result)
+		assert.Len(t, rejected, 1)
+		assert.Equal(t, 1, rejected[0].Line)
+		assert.Nil(t, rejected[0].Record)
+	})
+
+	t.Run("header errors are still fatal", func(t *testing.T) {
+		csv := `WrongColumn,Age
+John,25`
+		m := New(strings.NewReader(csv),
+			[]string{"Name", "Age"},
+			testPersonMapper)
+
+		result, rejected, err := m.MapAllLenient()
+		assert.ErrorIs(t, err, ErrHeaderMismatch)
+		assert.Nil(t, result)
+		assert.Nil(t, rejected)
+	})
+
+	t.Run("all rows valid", func(t *testing.T) {
+		csv := `Name,Age
+John,25`
+		m := New(strings.NewReader(csv),
+			[]string{"Name", "Age"},
+			testPersonMapper)
+
+		result, rejected, err := m.MapAllLenient()
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.Empty(t, rejected)
+	})
+}
+
+func TestMapperAll(t *testing.T) {
+	t.Run("yields records and row errors", func(t *testing.T) {
+		csv := `Name,Age
+John,25
+Jane,unknown
+Alice,30`
+		m := New(strings.NewReader(csv),
+			[]string{"Name", "Age"},
+			testPersonMapper)
+
+		var names []string
+		var rowErrs []*RowError
+		for person, err := range m.All() {
+			var rowErr *RowError
+			if errors.As(err, &rowErr) {
+				rowErrs = append(rowErrs, rowErr)
+				continue
+			}
+			assert.NoError(t, err)
+			names = append(names, person.Name)
+		}
+
+		assert.Equal(t, []string{"John", "Alice"}, names)
+		assert.Len(t, rowErrs, 1)
+		assert.Equal(t, 2, rowErrs[0].Line)
+	})
+
+	t.Run("header error ends iteration", func(t *testing.T) {
+		m := New(strings.NewReader(``),
+			[]string{"Name", "Age"},
+			testPersonMapper)
+
+		var errs []error
+		for _, err := range m.All() {
+			errs = append(errs, err)
+		}
+
+		assert.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], ErrEmptyFile)
+	})
+
+	t.Run("stops when consumer breaks", func(t *testing.T) {
+		csv := `Name,Age
+John,25
+Jane,30`
+		m := New(strings.NewReader(csv),
+			[]string{"Name", "Age"},
+			testPersonMapper)
+
+		count := 0
+		for range m.All() {
+			count++
+			break
+		}
+
+		assert.Equal(t, 1, count)
+	})
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkarmon/swiftcodes/internal/apikey"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+type APIKeyDTO struct {
+	ID          int64      `json:"id"`
+	Prefix      string     `json:"prefix"`
+	Owner       string     `json:"owner"`
+	Scopes      []string   `json:"scopes"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+	RotatedFrom *int64     `json:"rotatedFrom,omitempty"`
+}
+
+type CreateAPIKeyRequest struct {
+	Owner     string     `json:"owner"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// IssuedAPIKeyResponse carries a newly issued key, it is the only time the key is shown.
+type IssuedAPIKeyResponse struct {
+	Key    string     `json:"key"`
+	APIKey *APIKeyDTO `json:"apiKey"`
+}
+
+type APIKeysResponse struct {
+	APIKeys []*APIKeyDTO `json:"apiKeys"`
+}
+
+func apiKeyToDTO(key *model.APIKey) *APIKeyDTO {
+	return &APIKeyDTO{
+		ID:          key.ID,
+		Prefix:      key.Prefix,
+		Owner:       key.Owner,
+		Scopes:      key.Scopes,
+		CreatedAt:   key.CreatedAt,
+		ExpiresAt:   key.ExpiresAt,
+		LastUsedAt:  key.LastUsedAt,
+		RevokedAt:   key.RevokedAt,
+		RotatedFrom: key.RotatedFrom,
+	}
+}
+
+func CreateAPIKey(keys *apikey.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := Decode[CreateAPIKeyRequest](r.Body)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, "invalid json data")
+			return
+		}
+
+		key, apiKey, err := keys.Create(r.Context(), apikey.Spec{
+			Owner:     data.Owner,
+			Scopes:    data.Scopes,
+			ExpiresAt: data.ExpiresAt,
+		})
+		if errors.Is(err, apikey.ErrInvalidSpec) {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			SendServerError(w)
+			return
+		}
+
+		Encode(w, http.StatusCreated, &IssuedAPIKeyResponse{Key: key, APIKey: apiKeyToDTO(apiKey)})
+	}
+}
+
+// ListAPIKeys lists the keys by owner, revoked ones only with includeRevoked=true.
+func ListAPIKeys(keys *apikey.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		includeRevoked, err := parseBoolQuery(r, "includeRevoked")
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		apiKeys, err := keys.List(r.Context(), includeRevoked)
+		if err != nil {
+			SendServerError(w)
+			return
+		}
+
+		resp := &APIKeysResponse{APIKeys: make([]*APIKeyDTO, len(apiKeys))}
+		for i, apiKey := range apiKeys {
+			resp.APIKeys[i] = apiKeyToDTO(apiKey)
+		}
+		Encode(w, http.StatusOK, resp)
+	}
+}
+
+// RotateAPIKey revokes a key and issues its replacement.
+func RotateAPIKey(keys *apikey.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseAPIKeyID(r)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		key, apiKey, err := keys.Rotate(r.Context(), id)
+		if errors.Is(err, repo.ErrNotFound) {
+			SendErrorMsg(w, http.StatusNotFound, "no active api key with this id")
+			return
+		}
+		if err != nil {
+			SendServerError(w)
+			return
+		}
+
+		Encode(w, http.StatusCreated, &IssuedAPIKeyResponse{Key: key, APIKey: apiKeyToDTO(apiKey)})
+	}
+}
+
+func RevokeAPIKey(keys *apikey.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseAPIKeyID(r)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = keys.Revoke(r.Context(), id)
+		if errors.Is(err, repo.ErrNotFound) {
+			SendErrorMsg(w, http.StatusNotFound, "no api key with this id or it is already revoked")
+			return
+		}
+		if err != nil {
+			SendServerError(w)
+			return
+		}
+
+		SendSuccessMsg(w, http.StatusOK, "api key revoked")
+	}
+}
+
+func parseAPIKeyID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.New("api key id must be a positive integer")
+	}
+	return id, nil
+}
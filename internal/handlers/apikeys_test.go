@@ -0,0 +1,117 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkarmon/swiftcodes/internal/apikey"
+	"github.com/pkarmon/swiftcodes/internal/auth"
+	"github.com/pkarmon/swiftcodes/internal/handlers"
+	"github.com/pkarmon/swiftcodes/internal/memory"
+	"github.com/pkarmon/swiftcodes/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeys(t *testing.T) {
+	keys := apikey.NewManager(memory.NewAPIKeyRepo(store), time.Minute)
+	admins := Must(auth.ParseStaticKeys("root:admin:root-key"))
+
+	r := mux.NewRouter()
+	r.HandleFunc("/api-keys", middleware.Require(auth.RoleAdmin, handlers.ListAPIKeys(keys))).Methods("GET")
+	r.HandleFunc("/api-keys", middleware.Require(auth.RoleAdmin, handlers.CreateAPIKey(keys))).Methods("POST")
+	r.HandleFunc("/api-keys/{id}/rotate", middleware.Require(auth.RoleAdmin, handlers.RotateAPIKey(keys))).Methods("POST")
+	r.HandleFunc("/api-keys/{id}", middleware.Require(auth.RoleAdmin, handlers.RevokeAPIKey(keys))).Methods("DELETE")
+	r.HandleFunc("/{swiftCode}", middleware.Require(auth.RoleReader, handlers.GetBankUnit(bankUnitRepo))).Methods("GET")
+	h := middleware.Authenticate(auth.NewAuthenticator(auth.KeyStores{admins, keys}, nil, auth.RoleNone))(r)
+
+	serve := func(method, target, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(middleware.APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	issue := func(t *testing.T, body string) handlers.IssuedAPIKeyResponse {
+		rec := serve("POST", "/api-keys", "root-key", body)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		resp, err := handlers.Decode[handlers.IssuedAPIKeyResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		return resp
+	}
+
+	t.Run("issued keys authenticate until revoked", withCleanup(func(t *testing.T) {
+		issued := issue(t, `{"owner": "ci", "scopes": ["reader"]}`)
+		assert.True(t, strings.HasPrefix(issued.Key, issued.APIKey.Prefix))
+		assert.Equal(t, "ci", issued.APIKey.Owner)
+		assert.Equal(t, []string{"reader"}, issued.APIKey.Scopes)
+
+		assert.Equal(t, http.StatusOK, serve("GET", "/BPKOPLPWXXX", issued.Key, "").Code)
+		assert.Equal(t, http.StatusForbidden, serve("GET", "/api-keys", issued.Key, "").Code)
+
+		rec := serve("GET", "/api-keys", "root-key", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		list, err := handlers.Decode[handlers.APIKeysResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Len(t, list.APIKeys, 1)
+		assert.NotNil(t, list.APIKeys[0].LastUsedAt)
+
+		revoke := fmt.Sprintf("/api-keys/%d", issued.APIKey.ID)
+		assert.Equal(t, http.StatusOK, serve("DELETE", revoke, "root-key", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, serve("GET", "/BPKOPLPWXXX", issued.Key, "").Code)
+		assert.Equal(t, http.StatusNotFound, serve("DELETE", revoke, "root-key", "").Code)
+
+		rec = serve("GET", "/api-keys?includeRevoked=true", "root-key", "")
+		list, err = handlers.Decode[handlers.APIKeysResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Len(t, list.APIKeys, 1)
+		assert.NotNil(t, list.APIKeys[0].RevokedAt)
+	}))
+
+	t.Run("rotate", withCleanup(func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		issued := issue(t, fmt.Sprintf(`{"owner": "ops", "scopes": ["editor"], "expiresAt": %q}`, expiresAt.Format(time.RFC3339)))
+
+		rotate := fmt.Sprintf("/api-keys/%d/rotate", issued.APIKey.ID)
+		rec := serve("POST", rotate, "root-key", "")
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		rotated, err := handlers.Decode[handlers.IssuedAPIKeyResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "ops", rotated.APIKey.Owner)
+		assert.Equal(t, []string{"editor"}, rotated.APIKey.Scopes)
+		assert.True(t, expiresAt.Equal(*rotated.APIKey.ExpiresAt))
+		assert.Equal(t, issued.APIKey.ID, *rotated.APIKey.RotatedFrom)
+
+		assert.Equal(t, http.StatusUnauthorized, serve("GET", "/BPKOPLPWXXX", issued.Key, "").Code)
+		assert.Equal(t, http.StatusOK, serve("GET", "/BPKOPLPWXXX", rotated.Key, "").Code)
+		assert.Equal(t, http.StatusNotFound, serve("POST", rotate, "root-key", "").Code)
+	}))
+
+	t.Run("invalid requests", withCleanup(func(t *testing.T) {
+		tests := []struct {
+			method, target, body string
+			status               int
+			message              string
+		}{
+			{"POST", "/api-keys", `{"scopes": ["reader"]}`, http.StatusBadRequest, "invalid API key: owner is required"},
+			{"POST", "/api-keys", `{"owner": "ci", "scopes": ["owner"]}`, http.StatusBadRequest, `invalid API key: unknown scope "owner"`},
+			{"POST", "/api-keys", `{"owner": "ci", "scopes": ["reader"], "expiresAt": "2001-01-01T00:00:00Z"}`,
+				http.StatusBadRequest, "invalid API key: expiry must be in the future"},
+			{"POST", "/api-keys", `{`, http.StatusBadRequest, "invalid json data"},
+			{"DELETE", "/api-keys/abc", "", http.StatusBadRequest, "api key id must be a positive integer"},
+			{"POST", "/api-keys/999/rotate", "", http.StatusNotFound, "no active api key with this id"},
+		}
+		for _, tt := range tests {
+			rec := serve(tt.method, tt.target, "root-key", tt.body)
+			assert.Equal(t, tt.status, rec.Code, tt.target)
+			errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.message, errMsg.Message)
+		}
+	}))
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+type AuditEventDTO struct {
+	ID         int64      `json:"id"`
+	OccurredAt time.Time  `json:"occurredAt"`
+	Actor      string     `json:"actor"`
+	Action     string     `json:"action"`
+	SwiftCode  string     `json:"swiftCode"`
+	RequestID  string     `json:"requestId,omitempty"`
+	Before     *BranchDTO `json:"before"`
+	After      *BranchDTO `json:"after"`
+}
+
+type AuditResponse struct {
+	Events     []*AuditEventDTO `json:"events"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+func auditEventToDTO(event *model.AuditEvent) *AuditEventDTO {
+	dto := &AuditEventDTO{
+		ID:         event.ID,
+		OccurredAt: event.OccurredAt,
+		Actor:      event.Actor,
+		Action:     string(event.Action),
+		SwiftCode:  event.SwiftCode.String(),
+		RequestID:  event.RequestID,
+	}
+	if event.Before != nil {
+		dto.Before = branchToDTO(event.Before)
+	}
+	if event.After != nil {
+		dto.After = branchToDTO(event.After)
+	}
+	return dto
+}
+
+// GetAuditEvents lists audit events, the most recent first. They can be filtered by
+// swiftCode, actor and the from (inclusive) and to (exclusive) RFC 3339 timestamps.
+func GetAuditEvents(auditRepo repo.Audit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseAuditQuery(r)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		events, err := auditRepo.List(r.Context(), filter)
+		if err != nil {
+			SendServerError(w)
+			return
+		}
+
+		resp := &AuditResponse{}
+		limit := filter.Limit - 1
+		if len(events) > limit {
+			events = events[:limit]
+			resp.NextCursor = encodeAuditCursor(events[limit-1].ID)
+		}
+		resp.Events = make([]*AuditEventDTO, len(events))
+		for i, event := range events {
+			resp.Events[i] = auditEventToDTO(event)
+		}
+
+		Encode(w, http.StatusOK, resp)
+	}
+}
+
+// parseAuditQuery reads the audit filters, like parseListQuery it asks for one event
+// more than the page size.
+func parseAuditQuery(r *http.Request) (repo.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := repo.AuditFilter{Actor: query.Get("actor")}
+
+	if v := query.Get("swiftCode"); v != "" {
+		swiftcode, err := model.NewSwiftCode(v)
+		if err != nil {
+			return repo.AuditFilter{}, err
+		}
+		filter.SwiftCode = swiftcode.String()
+	}
+
+	var err error
+	if filter.From, err = parseTimeQuery(r, "from"); err != nil {
+		return repo.AuditFilter{}, err
+	}
+	if filter.To, err = parseTimeQuery(r, "to"); err != nil {
+		return repo.AuditFilter{}, err
+	}
+
+	limit, err := parseLimitQuery(r)
+	if err != nil {
+		return repo.AuditFilter{}, err
+	}
+	filter.Limit = limit + 1
+
+	beforeID, err := decodeAuditCursor(query.Get("cursor"))
+	if err != nil {
+		return repo.AuditFilter{}, err
+	}
+	filter.BeforeID = beforeID
+
+	return filter, nil
+}
+
+func encodeAuditCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeAuditCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errInvalidCursor
+	}
+	return id, nil
+}
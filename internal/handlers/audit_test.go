@@ -0,0 +1,139 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkarmon/swiftcodes/internal/auth"
+	"github.com/pkarmon/swiftcodes/internal/handlers"
+	"github.com/pkarmon/swiftcodes/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditEvents(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/audit", handlers.GetAuditEvents(auditRepo)).Methods("GET")
+	r.HandleFunc("/{swiftCode}", handlers.PatchBankUnit(bankUnitRepo)).Methods("PATCH")
+	r.HandleFunc("/{swiftCode}", handlers.DeleteBankUnit(bankUnitRepo)).Methods("DELETE")
+	r.HandleFunc("/", handlers.CreateBankUnit(bankUnitRepo, countryRepo)).Methods("POST")
+	keys := Must(auth.ParseStaticKeys(
+		"alice:editor:alice-key,bob:editor:bob-key,carol:editor:carol-key," +
+			"dave:editor:dave-key,erin:editor:erin-key,auditor:admin:auditor-key"))
+	h := middleware.Audit(middleware.Authenticate(auth.NewAuthenticator(keys, nil, auth.RoleNone))(r))
+
+	serve := func(method, target, actor, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(middleware.APIKeyHeader, actor+"-key")
+		req.Header.Set(middleware.RequestIDHeader, actor+"-request")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	listEvents := func(t *testing.T, query string) handlers.AuditResponse {
+		rec := serve("GET", "/audit?"+query, "auditor", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		resp, err := handlers.Decode[handlers.AuditResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		return resp
+	}
+
+	t.Run("mutations are recorded with before and after", withCleanup(func(t *testing.T) {
+		body := `{
+			"swiftCode": "DEUTDEFFXXX",
+			"countryISO2": "DE",
+			"countryName": "GERMANY",
+			"address": "TAUNUSANLAGE 12 FRANKFURT AM MAIN, HESSEN, 60325",
+			"bankName": "DEUTSCHE BANK AG",
+			"isHeadquarter": true
+		}`
+		assert.Equal(t, http.StatusCreated, serve("POST", "/", "alice", body).Code)
+		assert.Equal(t, http.StatusOK, serve("PATCH", "/DEUTDEFFXXX", "bob", `{"bankName": "DEUTSCHE BANK"}`).Code)
+		assert.Equal(t, http.StatusOK, serve("DELETE", "/DEUTDEFFXXX", "alice", "").Code)
+
+		resp := listEvents(t, "swiftCode=DEUTDEFF")
+		assert.Len(t, resp.Events, 3)
+
+		deleted, updated, created := resp.Events[0], resp.Events[1], resp.Events[2]
+
+		assert.Equal(t, "create", created.Action)
+		assert.Equal(t, "alice", created.Actor)
+		assert.Equal(t, "alice-request", created.RequestID)
+		assert.Nil(t, created.Before)
+		assert.Equal(t, "DEUTSCHE BANK AG", created.After.Name)
+
+		assert.Equal(t, "update", updated.Action)
+		assert.Equal(t, "bob", updated.Actor)
+		assert.Equal(t, "DEUTSCHE BANK AG", updated.Before.Name)
+		assert.Equal(t, "DEUTSCHE BANK", updated.After.Name)
+
+		assert.Equal(t, "delete", deleted.Action)
+		assert.Equal(t, "DEUTSCHE BANK", deleted.Before.Name)
+		assert.Nil(t, deleted.After)
+	}))
+
+	t.Run("cascade delete records every branch", withCleanup(func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("DELETE", "/BPKOPLPWXXX?cascade=true", "carol", "").Code)
+
+		resp := listEvents(t, "actor=carol")
+		codes := []string{}
+		for _, event := range resp.Events {
+			assert.Equal(t, "delete", event.Action)
+			codes = append(codes, event.SwiftCode)
+		}
+		assert.ElementsMatch(t, []string{"BPKOPLPWXXX", "BPKOPLPWCSD", "BPKOPLPWGDG"}, codes)
+	}))
+
+	t.Run("failed mutations are not recorded", withCleanup(func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, serve("DELETE", "/BPKOPLPWXXX", "dave", "").Code)
+
+		assert.Empty(t, listEvents(t, "actor=dave").Events)
+	}))
+
+	t.Run("paginate and filter by time", withCleanup(func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("DELETE", "/BPKOPLPWXXX?cascade=true", "erin", "").Code)
+
+		first := listEvents(t, "actor=erin&limit=2")
+		assert.Len(t, first.Events, 2)
+		assert.NotEmpty(t, first.NextCursor)
+
+		second := listEvents(t, "actor=erin&limit=2&cursor="+first.NextCursor)
+		assert.Len(t, second.Events, 1)
+		assert.Empty(t, second.NextCursor)
+		assert.Less(t, second.Events[0].ID, first.Events[1].ID)
+
+		assert.Empty(t, listEvents(t, "actor=erin&from=2999-01-01T00:00:00Z").Events)
+		assert.Len(t, listEvents(t, "actor=erin&to=2999-01-01T00:00:00Z").Events, 3)
+	}))
+
+	t.Run("invalid filters", func(t *testing.T) {
+		for query, message := range map[string]string{
+			"swiftCode=INVALID": "swift code length must be 8 or 11 characters",
+			"from=yesterday":    "from must be an RFC 3339 timestamp",
+			"to=tomorrow":       "to must be an RFC 3339 timestamp",
+			"cursor=!!":         "invalid cursor",
+			"limit=0":           "limit must be an integer between 1 and 1000",
+		} {
+			rec := serve("GET", "/audit?"+query, "auditor", "")
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+			errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+			assert.Nil(t, err)
+			assert.Equal(t, message, errMsg.Message, query)
+		}
+	})
+
+	t.Run("request id is echoed", func(t *testing.T) {
+		rec := serve("GET", "/audit", "auditor", "")
+		assert.Equal(t, "auditor-request", rec.Header().Get(middleware.RequestIDHeader))
+
+		req := httptest.NewRequest("GET", "/audit", nil)
+		req.Header.Set(middleware.APIKeyHeader, "auditor-key")
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Len(t, rec.Header().Get(middleware.RequestIDHeader), 32)
+	})
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pkarmon/swiftcodes/internal/model"
@@ -10,12 +12,23 @@ import (
 )
 
 type BranchDTO struct {
-	Address       string `json:"address"`
-	Name          string `json:"bankName"`
-	CountryISO2   string `json:"countryISO2"`
-	CountryName   string `json:"countryName"`
-	IsHeadquarter bool   `json:"isHeadquarter"`
-	SwiftCode     string `json:"swiftCode"`
+	Address              string     `json:"address"`
+	Name                 string     `json:"bankName"`
+	CountryISO2          string     `json:"countryISO2"`
+	CountryName          string     `json:"countryName"`
+	IsHeadquarter        bool       `json:"isHeadquarter"`
+	SwiftCode            string     `json:"swiftCode"`
+	CodeType             string     `json:"codeType"`
+	TownName             string     `json:"townName"`
+	TimeZone             string     `json:"timeZone"`
+	LocationCode         string     `json:"locationCode"`
+	IsTestBIC            bool       `json:"isTestBIC"`
+	IsPassiveParticipant bool       `json:"isPassiveParticipant"`
+	IsReverseBilling     bool       `json:"isReverseBilling"`
+	MatchedForm          string     `json:"matchedForm,omitempty"`
+	DeletedAt            *time.Time `json:"deletedAt,omitempty"`
+	ValidFrom            *time.Time `json:"validFrom,omitempty"`
+	ValidTo              *time.Time `json:"validTo,omitempty"`
 }
 
 type HeadquartersDTO struct {
@@ -27,17 +40,34 @@ type SwiftCodeForCountryResponse struct {
 	CountryISO2 string       `json:"countryISO2"`
 	CountryName string       `json:"countryName"`
 	SwiftCodes  []*BranchDTO `json:"swiftCodes"`
+	// NextCursor is set when there are more bank units, pass it as the cursor
+	// query parameter to get the next page.
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 func branchToDTO(bu *model.BankUnit) *BranchDTO {
-	return &BranchDTO{
-		Address:       bu.Address,
-		Name:          bu.Name,
-		CountryISO2:   bu.Country.Code.String(),
-		CountryName:   bu.Country.Name,
-		IsHeadquarter: bu.IsHeadquarter,
-		SwiftCode:     bu.SwiftCode.String(),
+	dto := &BranchDTO{
+		Address:              bu.Address,
+		Name:                 bu.Name,
+		CountryISO2:          bu.Country.Code.String(),
+		CountryName:          bu.Country.Name,
+		IsHeadquarter:        bu.IsHeadquarter,
+		SwiftCode:            bu.SwiftCode.String(),
+		CodeType:             bu.CodeType,
+		TownName:             bu.TownName,
+		TimeZone:             bu.TimeZone,
+		LocationCode:         bu.SwiftCode.LocationCode(),
+		IsTestBIC:            bu.SwiftCode.IsTestBIC(),
+		IsPassiveParticipant: bu.SwiftCode.IsPassiveParticipant(),
+		IsReverseBilling:     bu.SwiftCode.IsReverseBilling(),
+		DeletedAt:            bu.DeletedAt,
+		ValidTo:              bu.ValidTo,
 	}
+	if !bu.ValidFrom.IsZero() {
+		validFrom := bu.ValidFrom
+		dto.ValidFrom = &validFrom
+	}
+	return dto
 }
 
 func headquartersToDTO(hq *model.BankUnit, branches []*model.BankUnit) *HeadquartersDTO {
@@ -64,7 +94,19 @@ func GetBankUnit(bankRepo repo.BankUnit) http.HandlerFunc {
 			return
 		}
 
-		bankUnit, err := bankRepo.GetBySwiftCode(r.Context(), swiftcode)
+		includeDeleted, err := parseBoolQuery(r, "includeDeleted")
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		asOf, err := parseTimeQuery(r, "asOf")
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts := repo.ReadOptions{IncludeDeleted: includeDeleted, AsOf: asOf}
+
+		bankUnit, err := bankRepo.GetBySwiftCode(r.Context(), swiftcode, opts)
 		if err != nil {
 			if errors.Is(err, repo.ErrNotFound) {
 				SendErrorMsg(w, http.StatusNotFound, "not found")
@@ -75,17 +117,74 @@ func GetBankUnit(bankRepo repo.BankUnit) http.HandlerFunc {
 		}
 
 		if bankUnit.IsHeadquarter {
-			branches, err := bankRepo.GetBranches(r.Context(), bankUnit.SwiftCode)
+			branches, err := bankRepo.GetBranches(r.Context(), bankUnit.SwiftCode, opts)
 			if err != nil {
 				SendServerError(w)
 				return
 			}
 			dto := headquartersToDTO(bankUnit, branches)
+			dto.MatchedForm = swiftcode.Form()
 			Encode(w, http.StatusOK, dto)
 		} else {
-			Encode(w, http.StatusOK, branchToDTO(bankUnit))
+			dto := branchToDTO(bankUnit)
+			dto.MatchedForm = swiftcode.Form()
+			Encode(w, http.StatusOK, dto)
+		}
+
+	}
+}
+
+type InstitutionMatchDTO struct {
+	InstitutionCode string       `json:"institutionCode"`
+	SwiftCodes      []*BranchDTO `json:"swiftCodes"`
+}
+
+type PatternMatchResponse struct {
+	Pattern      string                 `json:"pattern"`
+	Institutions []*InstitutionMatchDTO `json:"institutions"`
+	NextCursor   string                 `json:"nextCursor,omitempty"`
+}
+
+// MatchBankUnits resolves a swift code prefix or a pattern with ? wildcards
+// to the matching bank units, grouped by institution code.
+func MatchBankUnits(bankRepo repo.BankUnit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pattern, err := model.NewSwiftCodePattern(r.URL.Query().Get("pattern"))
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		opts, err := parseListQuery(r)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		bankUnits, err := bankRepo.FindByPattern(r.Context(), pattern, opts)
+		if err != nil {
+			SendServerError(w)
+			return
+		}
+
+		bankUnits, nextCursor := trimPage(bankUnits, opts)
+
+		res := PatternMatchResponse{
+			Pattern:      pattern.String(),
+			Institutions: []*InstitutionMatchDTO{},
+			NextCursor:   nextCursor,
+		}
+		// bank units are ordered by swift code, so each institution forms a continuous run
+		var current *InstitutionMatchDTO
+		for _, bu := range bankUnits {
+			if current == nil || current.InstitutionCode != bu.SwiftCode.InstitutionCode() {
+				current = &InstitutionMatchDTO{InstitutionCode: bu.SwiftCode.InstitutionCode()}
+				res.Institutions = append(res.Institutions, current)
+			}
+			current.SwiftCodes = append(current.SwiftCodes, branchToDTO(bu))
 		}
 
+		Encode(w, http.StatusOK, &res)
 	}
 }
 
@@ -98,28 +197,233 @@ func GetAllBankUnitsForCountry(bankRepo repo.BankUnit, countryRepo repo.Country)
 			return
 		}
 
+		opts, err := parseListQuery(r)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
 		country, err := countryRepo.GetByCode(r.Context(), code)
 		if errors.Is(err, repo.ErrNotFound) {
 			SendErrorMsg(w, http.StatusNotFound, "country not found")
 			return
 		}
 
-		bankUnits, err := bankRepo.GetAllByCountry(r.Context(), code)
+		bankUnits, err := bankRepo.GetAllByCountry(r.Context(), code, opts)
 		if err != nil {
 			SendServerError(w)
 			return
 		}
 
+		bankUnits, nextCursor := trimPage(bankUnits, opts)
+
 		res := SwiftCodeForCountryResponse{
 			CountryISO2: country.Code.String(),
 			CountryName: country.Name,
 			SwiftCodes:  branchesToDTOS(bankUnits),
+			NextCursor:  nextCursor,
 		}
 
 		Encode(w, http.StatusOK, &res)
 	}
 }
 
+// BankUnitPatch holds the fields to change in a PATCH request, omitted fields are kept.
+type BankUnitPatch struct {
+	Address       *string `json:"address"`
+	Name          *string `json:"bankName"`
+	CountryISO2   *string `json:"countryISO2"`
+	CountryName   *string `json:"countryName"`
+	IsHeadquarter *bool   `json:"isHeadquarter"`
+	SwiftCode     *string `json:"swiftCode"`
+	CodeType      *string `json:"codeType"`
+	TownName      *string `json:"townName"`
+	TimeZone      *string `json:"timeZone"`
+}
+
+func (p *BankUnitPatch) apply(data *BranchDTO) {
+	setIfPresent(&data.Address, p.Address)
+	setIfPresent(&data.Name, p.Name)
+	setIfPresent(&data.CountryISO2, p.CountryISO2)
+	setIfPresent(&data.CountryName, p.CountryName)
+	setIfPresent(&data.IsHeadquarter, p.IsHeadquarter)
+	setIfPresent(&data.SwiftCode, p.SwiftCode)
+	setIfPresent(&data.CodeType, p.CodeType)
+	setIfPresent(&data.TownName, p.TownName)
+	setIfPresent(&data.TimeZone, p.TimeZone)
+}
+
+func setIfPresent[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// UpdateBankUnit replaces an existing bank unit with the request data.
+// The swift code and the country may be omitted, but cannot be changed.
+func UpdateBankUnit(bankRepo repo.BankUnit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		existing, ok := getBankUnitForUpdate(w, r, bankRepo)
+		if !ok {
+			return
+		}
+
+		data, err := Decode[BranchDTO](r.Body)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, "invalid json data")
+			return
+		}
+
+		saveBankUnitUpdate(w, r, bankRepo, existing, &data)
+	}
+}
+
+// PatchBankUnit changes only the fields present in the request.
+func PatchBankUnit(bankRepo repo.BankUnit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		existing, ok := getBankUnitForUpdate(w, r, bankRepo)
+		if !ok {
+			return
+		}
+
+		patch, err := Decode[BankUnitPatch](r.Body)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, "invalid json data")
+			return
+		}
+
+		data := branchToDTO(existing)
+		patch.apply(data)
+
+		saveBankUnitUpdate(w, r, bankRepo, existing, data)
+	}
+}
+
+func getBankUnitForUpdate(w http.ResponseWriter, r *http.Request, bankRepo repo.BankUnit) (*model.BankUnit, bool) {
+	swiftcode, err := model.NewSwiftCode(mux.Vars(r)["swiftCode"])
+	if err != nil {
+		SendErrorMsg(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	existing, err := bankRepo.GetBySwiftCode(r.Context(), swiftcode, repo.ReadOptions{})
+	if errors.Is(err, repo.ErrNotFound) {
+		SendErrorMsg(w, http.StatusNotFound, "not found")
+		return nil, false
+	}
+	if err != nil {
+		SendServerError(w)
+		return nil, false
+	}
+
+	return existing, true
+}
+
+func saveBankUnitUpdate(w http.ResponseWriter, r *http.Request, bankRepo repo.BankUnit, existing *model.BankUnit, data *BranchDTO) {
+	if data.SwiftCode == "" {
+		data.SwiftCode = existing.SwiftCode.String()
+	}
+	if sc, err := model.NewSwiftCode(data.SwiftCode); err != nil || sc.String() != existing.SwiftCode.String() {
+		SendErrorMsg(w, http.StatusBadRequest, "swift code cannot be changed")
+		return
+	}
+
+	if data.CountryISO2 == "" {
+		data.CountryISO2 = existing.Country.Code.String()
+	}
+	if data.CountryName == "" {
+		data.CountryName = existing.Country.Name
+	}
+	if strings.ToUpper(data.CountryISO2) != existing.Country.Code.String() ||
+		strings.ToUpper(data.CountryName) != existing.Country.Name {
+		SendErrorMsg(w, http.StatusBadRequest, "country cannot be changed")
+		return
+	}
+
+	// a PUT omitting the flag must not silently demote a headquarters
+	if existing.SwiftCode.HasHeadQuartersBranchCode() && !data.IsHeadquarter {
+		SendErrorMsg(w, http.StatusBadRequest, "bank unit with branch code XXX must be a headquarter")
+		return
+	}
+
+	bu, err := bankUnitFromDTO(data)
+	if err != nil {
+		SendErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = bankRepo.Update(r.Context(), bu)
+	if errors.Is(err, repo.ErrNotFound) {
+		SendErrorMsg(w, http.StatusNotFound, "not found")
+		return
+	}
+	if err != nil {
+		SendServerError(w)
+		return
+	}
+
+	Encode(w, http.StatusOK, branchToDTO(bu))
+}
+
+type DeletedBankUnitsResponse struct {
+	SwiftCodes []*BranchDTO `json:"swiftCodes"`
+}
+
+// GetDeletedBankUnits lists deleted bank units, the most recently deleted first.
+// The optional since query parameter (RFC 3339) skips older deletions.
+func GetDeletedBankUnits(bankRepo repo.BankUnit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		since, err := parseTimeQuery(r, "since")
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts := repo.DeletedListOptions{Since: since}
+
+		limit, err := parseLimitQuery(r)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.Limit = limit
+
+		bankUnits, err := bankRepo.GetDeleted(r.Context(), opts)
+		if err != nil {
+			SendServerError(w)
+			return
+		}
+
+		Encode(w, http.StatusOK, &DeletedBankUnitsResponse{SwiftCodes: branchesToDTOS(bankUnits)})
+	}
+}
+
+// RestoreBankUnit brings back the most recently deleted bank unit with the swift code.
+func RestoreBankUnit(bankRepo repo.BankUnit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		swiftcode, err := model.NewSwiftCode(mux.Vars(r)["swiftCode"])
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		bankUnit, err := bankRepo.Restore(r.Context(), swiftcode)
+		if errors.Is(err, repo.ErrNotFound) {
+			SendErrorMsg(w, http.StatusNotFound, "no deleted bank unit with this swift code")
+			return
+		}
+		if errors.Is(err, repo.ErrDuplicate) {
+			SendErrorMsg(w, http.StatusConflict, "swift code was created again after it had been deleted")
+			return
+		}
+		if err != nil {
+			SendServerError(w)
+			return
+		}
+
+		Encode(w, http.StatusOK, branchToDTO(bankUnit))
+	}
+}
+
 func DeleteBankUnit(bankRepo repo.BankUnit) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		sc := mux.Vars(r)["swiftCode"]
@@ -129,7 +433,22 @@ func DeleteBankUnit(bankRepo repo.BankUnit) http.HandlerFunc {
 			return
 		}
 
-		if err := bankRepo.Delete(r.Context(), swiftcode); err != nil {
+		cascade, err := parseBoolQuery(r, "cascade")
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = bankRepo.Delete(r.Context(), swiftcode, repo.DeleteOptions{Cascade: cascade})
+		if errors.Is(err, repo.ErrNotFound) {
+			SendErrorMsg(w, http.StatusNotFound, "not found")
+			return
+		}
+		if errors.Is(err, repo.ErrHasBranches) {
+			SendErrorMsg(w, http.StatusConflict, "headquarters still has branches, use cascade=true to delete them too")
+			return
+		}
+		if err != nil {
 			SendServerError(w)
 			return
 		}
@@ -138,6 +457,31 @@ func DeleteBankUnit(bankRepo repo.BankUnit) http.HandlerFunc {
 	}
 }
 
+// bankUnitFromDTO validates the request data the same way model.NewBankUnit does,
+// together with the optional directory details.
+func bankUnitFromDTO(data *BranchDTO) (*model.BankUnit, error) {
+	bu, err := model.NewBankUnit(
+		data.SwiftCode,
+		data.CountryISO2,
+		data.CountryName,
+		data.Address,
+		data.Name,
+		data.IsHeadquarter,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := model.ValidateTimeZone(data.TimeZone); err != nil {
+		return nil, err
+	}
+	bu.CodeType = data.CodeType
+	bu.TownName = data.TownName
+	bu.TimeZone = data.TimeZone
+
+	return bu, nil
+}
+
 func CreateBankUnit(bankRepo repo.BankUnit, countryRepo repo.Country) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		data, err := Decode[BranchDTO](r.Body)
@@ -161,14 +505,7 @@ func CreateBankUnit(bankRepo repo.BankUnit, countryRepo repo.Country) http.Handl
 			return
 		}
 
-		bu, err := model.NewBankUnit(
-			data.SwiftCode,
-			data.CountryISO2,
-			data.CountryName,
-			data.Address,
-			data.Name,
-			data.IsHeadquarter,
-		)
+		bu, err := bankUnitFromDTO(&data)
 		if err != nil {
 			SendErrorMsg(w, http.StatusBadRequest, err.Error())
 			return
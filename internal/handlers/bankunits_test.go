@@ -7,30 +7,30 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pkarmon/swiftcodes/internal/handlers"
+	"github.com/pkarmon/swiftcodes/internal/memory"
 	"github.com/pkarmon/swiftcodes/internal/model"
-	"github.com/pkarmon/swiftcodes/internal/postgres"
 	"github.com/pkarmon/swiftcodes/internal/repo"
 	"github.com/stretchr/testify/assert"
 )
 
 var (
-	db           postgres.DB
+	store        *memory.Store
 	bankUnitRepo repo.BankUnit
 	countryRepo  repo.Country
+	auditRepo    repo.Audit
 )
 
 func TestMain(m *testing.M) {
 	ctx := context.Background()
-	createdDb, cleanup, err := postgres.ConfigureTestDB(ctx)
-	exitIfErr(err)
-	defer cleanup()
 
-	db = createdDb
-	bankUnitRepo = postgres.NewBankUnitRepo(db)
-	countryRepo = postgres.NewCountryRepo(db)
+	store = memory.NewStore()
+	bankUnitRepo = memory.NewBankUnitRepo(store)
+	countryRepo = memory.NewCountryRepo(store)
+	auditRepo = memory.NewAuditRepo(store)
 
 	resetTestData(ctx)
 
@@ -38,8 +38,7 @@ func TestMain(m *testing.M) {
 }
 
 func resetTestData(ctx context.Context) {
-	_ = db.DropSchema(context.Background())
-	exitIfErr(db.SetupSchema(ctx))
+	store.Clear()
 
 	pl := Must(model.NewCountry("PL", "POLAND"))
 	bg := Must(model.NewCountry("BG", "BULGARIA"))
@@ -105,6 +104,7 @@ func TestGetBankUnit(t *testing.T) {
 		assert.Equal(t, "POLAND", hq.CountryName)
 		assert.Equal(t, true, hq.IsHeadquarter)
 		assert.Equal(t, "BPKOPLPWXXX", hq.SwiftCode)
+		assert.Equal(t, model.FormBIC11, hq.MatchedForm)
 		assert.Len(t, hq.Branches, 2)
 		branchSwiftCodes := []string{hq.Branches[0].SwiftCode, hq.Branches[1].SwiftCode}
 		assert.Contains(t, branchSwiftCodes, "BPKOPLPWCSD")
@@ -113,6 +113,21 @@ func TestGetBankUnit(t *testing.T) {
 		assert.False(t, hq.Branches[1].IsHeadquarter)
 	})
 
+	t.Run("get hq by BIC8", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/BPKOPLPW", nil)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		hq, err := handlers.Decode[handlers.HeadquartersDTO](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "BPKOPLPWXXX", hq.SwiftCode)
+		assert.Equal(t, model.FormBIC8, hq.MatchedForm)
+		assert.True(t, hq.IsHeadquarter)
+		assert.Len(t, hq.Branches, 2)
+	})
+
 	t.Run("get branch", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/BPKOPLPWCSD", nil)
 		rec := httptest.NewRecorder()
@@ -128,6 +143,7 @@ func TestGetBankUnit(t *testing.T) {
 		assert.Equal(t, "POLAND", branch.CountryName)
 		assert.Equal(t, false, branch.IsHeadquarter)
 		assert.Equal(t, "BPKOPLPWCSD", branch.SwiftCode)
+		assert.Equal(t, model.FormBIC11, branch.MatchedForm)
 	})
 
 	t.Run("not found", func(t *testing.T) {
@@ -148,7 +164,109 @@ func TestGetBankUnit(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
 		assert.Nil(t, err)
-		assert.Equal(t, "swift code length must be 11 characters", errMsg.Message)
+		assert.Equal(t, "swift code length must be 8 or 11 characters", errMsg.Message)
+	})
+
+	t.Run("malformed swift code", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/BPKOP1PWXXX", nil)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, model.ErrInvalidCountryCode.Error(), errMsg.Message)
+	})
+}
+
+func TestMatchBankUnits(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/match", handlers.MatchBankUnits(bankUnitRepo)).Methods("GET")
+
+	match := func(t *testing.T, query string) handlers.PatternMatchResponse {
+		req := httptest.NewRequest("GET", "/match?"+query, nil)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		response, err := handlers.Decode[handlers.PatternMatchResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		return response
+	}
+
+	grouped := func(response handlers.PatternMatchResponse) map[string][]string {
+		groups := map[string][]string{}
+		for _, institution := range response.Institutions {
+			for _, bu := range institution.SwiftCodes {
+				groups[institution.InstitutionCode] = append(groups[institution.InstitutionCode], bu.SwiftCode)
+			}
+		}
+		return groups
+	}
+
+	t.Run("match patterns", withCleanup(func(t *testing.T) {
+		exitIfErr(bankUnitRepo.BulkCreate(context.Background(), []*model.BankUnit{
+			Must(model.NewBankUnit("DEUTDEFFXXX", "DE", "GERMANY", "FRANKFURT", "DEUTSCHE BANK AG", true)),
+			Must(model.NewBankUnit("DEUTDEFF500", "DE", "GERMANY", "FRANKFURT", "DEUTSCHE BANK AG", false)),
+			Must(model.NewBankUnit("DEUTDEDBXXX", "DE", "GERMANY", "BERLIN", "DEUTSCHE BANK AG", true)),
+			Must(model.NewBankUnit("DRESDEFFXXX", "DE", "GERMANY", "FRANKFURT", "COMMERZBANK", true)),
+		}))
+
+		tests := []struct {
+			pattern string
+			want    map[string][]string
+		}{
+			{"BPKO", map[string][]string{"BPKO": {"BPKOPLPWCSD", "BPKOPLPWGDG", "BPKOPLPWXXX"}}},
+			{"deutde", map[string][]string{"DEUT": {"DEUTDEDBXXX", "DEUTDEFF500", "DEUTDEFFXXX"}}},
+			{"DEUTDEFF", map[string][]string{"DEUT": {"DEUTDEFF500", "DEUTDEFFXXX"}}},
+			{"DEUTDEFF5%3F%3F", map[string][]string{"DEUT": {"DEUTDEFF500"}}},
+			{"%3F%3F%3F%3FDEFF", map[string][]string{
+				"DEUT": {"DEUTDEFF500", "DEUTDEFFXXX"},
+				"DRES": {"DRESDEFFXXX"},
+			}},
+			{"ABCD", map[string][]string{}},
+		}
+
+		for _, tt := range tests {
+			response := match(t, "pattern="+tt.pattern)
+			assert.Equal(t, tt.want, grouped(response), tt.pattern)
+		}
+	}))
+
+	t.Run("paginate matches", func(t *testing.T) {
+		first := match(t, "pattern=BPKOPLPW&limit=2")
+		assert.Equal(t, map[string][]string{"BPKO": {"BPKOPLPWCSD", "BPKOPLPWGDG"}}, grouped(first))
+		assert.NotEmpty(t, first.NextCursor)
+
+		second := match(t, "pattern=BPKOPLPW&limit=2&cursor="+first.NextCursor)
+		assert.Equal(t, map[string][]string{"BPKO": {"BPKOPLPWXXX"}}, grouped(second))
+		assert.Empty(t, second.NextCursor)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		tests := []struct {
+			pattern string
+			message string
+		}{
+			{"", "pattern length must be 4, 6, 8 or 11 characters"},
+			{"DEUTDEF", "pattern length must be 4, 6, 8 or 11 characters"},
+			{"DEU1", "pattern must follow the swift code structure, with ? matching any character"},
+			{"DEUT%25%25", "pattern must follow the swift code structure, with ? matching any character"},
+		}
+
+		for _, tt := range tests {
+			req := httptest.NewRequest("GET", "/match?pattern="+tt.pattern, nil)
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, tt.pattern)
+			errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.message, errMsg.Message, tt.pattern)
+		}
 	})
 }
 
@@ -201,6 +319,114 @@ func TestGetAllBankUnitsForCountry(t *testing.T) {
 		assert.Equal(t, "country ISO2 code length must be 2 characters", errMsg.Message)
 	})
 
+	t.Run("exclude test BICs", withCleanup(func(t *testing.T) {
+		testBIC := Must(model.NewBankUnit("DEUTDEF0XXX", "DE", "GERMANY", "FRANKFURT", "DEUTSCHE BANK TEST", true))
+		live := Must(model.NewBankUnit("DEUTDEFFXXX", "DE", "GERMANY", "FRANKFURT", "DEUTSCHE BANK AG", true))
+		exitIfErr(bankUnitRepo.BulkCreate(context.Background(), []*model.BankUnit{testBIC, live}))
+
+		req := httptest.NewRequest("GET", "/DE?excludeTestBICs=true", nil)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		response, err := handlers.Decode[handlers.SwiftCodeForCountryResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Len(t, response.SwiftCodes, 1)
+		assert.Equal(t, "DEUTDEFFXXX", response.SwiftCodes[0].SwiftCode)
+		assert.False(t, response.SwiftCodes[0].IsTestBIC)
+		assert.Equal(t, "FF", response.SwiftCodes[0].LocationCode)
+
+		req = httptest.NewRequest("GET", "/DE", nil)
+		rec = httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		response, err = handlers.Decode[handlers.SwiftCodeForCountryResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Len(t, response.SwiftCodes, 2)
+	}))
+
+	t.Run("invalid excludeTestBICs value", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/DE?excludeTestBICs=maybe", nil)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "excludeTestBICs must be a boolean", errMsg.Message)
+	})
+
+	t.Run("paginate with cursor", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/PL?limit=2", nil)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		first, err := handlers.Decode[handlers.SwiftCodeForCountryResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Len(t, first.SwiftCodes, 2)
+		assert.Equal(t, "BPKOPLPWCSD", first.SwiftCodes[0].SwiftCode)
+		assert.Equal(t, "BPKOPLPWGDG", first.SwiftCodes[1].SwiftCode)
+		assert.NotEmpty(t, first.NextCursor)
+
+		req = httptest.NewRequest("GET", "/PL?limit=2&cursor="+first.NextCursor, nil)
+		rec = httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		second, err := handlers.Decode[handlers.SwiftCodeForCountryResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Len(t, second.SwiftCodes, 1)
+		assert.Equal(t, "BPKOPLPWXXX", second.SwiftCodes[0].SwiftCode)
+		assert.Empty(t, second.NextCursor)
+	})
+
+	t.Run("exact page has no next cursor", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/PL?limit=3", nil)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		response, err := handlers.Decode[handlers.SwiftCodeForCountryResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Len(t, response.SwiftCodes, 3)
+		assert.Empty(t, response.NextCursor)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		for _, limit := range []string{"0", "-1", "1001", "abc"} {
+			req := httptest.NewRequest("GET", "/PL?limit="+limit, nil)
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, limit)
+			errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+			assert.Nil(t, err)
+			assert.Equal(t, "limit must be an integer between 1 and 1000", errMsg.Message)
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		for _, cursor := range []string{"!!!", "Zm9v"} {
+			req := httptest.NewRequest("GET", "/PL?cursor="+cursor, nil)
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, cursor)
+			errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+			assert.Nil(t, err)
+			assert.Equal(t, "invalid cursor", errMsg.Message)
+		}
+	})
+
 	t.Run("country that does not have any bank units", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/DE", nil)
 		rec := httptest.NewRecorder()
@@ -216,12 +442,135 @@ func TestGetAllBankUnitsForCountry(t *testing.T) {
 	})
 }
 
+func TestUpdateBankUnit(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/{swiftCode}", handlers.UpdateBankUnit(bankUnitRepo)).Methods("PUT")
+	r.HandleFunc("/{swiftCode}", handlers.PatchBankUnit(bankUnitRepo)).Methods("PATCH")
+
+	send := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("put replaces the bank unit", withCleanup(func(t *testing.T) {
+		rec := send("PUT", "/BPKOPLPWGDG", `{
+			"address": "SWIETOJANSKA 19  GDYNIA, POMORSKIE, 81-368",
+			"bankName": "PKO BANK POLSKI S.A.",
+			"isHeadquarter": false,
+			"townName": "GDYNIA",
+			"timeZone": "Europe/Warsaw"
+		}`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		dto, err := handlers.Decode[handlers.BranchDTO](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "BPKOPLPWGDG", dto.SwiftCode)
+		assert.Equal(t, "SWIETOJANSKA 19  GDYNIA, POMORSKIE, 81-368", dto.Address)
+
+		bankUnit, err := bankUnitRepo.GetBySwiftCode(context.Background(), Must(model.NewSwiftCode("BPKOPLPWGDG")), repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "SWIETOJANSKA 19  GDYNIA, POMORSKIE, 81-368", bankUnit.Address)
+		assert.Equal(t, "GDYNIA", bankUnit.TownName)
+		assert.Equal(t, "Europe/Warsaw", bankUnit.TimeZone)
+		assert.Equal(t, "POLAND", bankUnit.Country.Name)
+	}))
+
+	t.Run("patch changes only given fields", withCleanup(func(t *testing.T) {
+		rec := send("PATCH", "/BPKOPLPW", `{"bankName": "PKO BP", "countryISO2": "pl"}`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		bankUnit, err := bankUnitRepo.GetBySwiftCode(context.Background(), Must(model.NewSwiftCode("BPKOPLPWXXX")), repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "PKO BP", bankUnit.Name)
+		assert.Equal(t, "UL. PULAWSKA 15  WARSZAWA, MAZOWIECKIE, 02-515", bankUnit.Address)
+		assert.True(t, bankUnit.IsHeadquarter)
+	}))
+
+	t.Run("put keeps a headquarter", withCleanup(func(t *testing.T) {
+		rec := send("PUT", "/BPKOPLPWXXX", `{
+			"address": "UL. PULAWSKA 15  WARSZAWA, MAZOWIECKIE, 02-515",
+			"bankName": "PKO BP",
+			"isHeadquarter": true
+		}`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		bankUnit, err := bankUnitRepo.GetBySwiftCode(context.Background(), Must(model.NewSwiftCode("BPKOPLPWXXX")), repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "PKO BP", bankUnit.Name)
+		assert.True(t, bankUnit.IsHeadquarter)
+
+		branches, err := bankUnitRepo.GetBranches(context.Background(), bankUnit.SwiftCode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, branches, 2)
+	}))
+
+	t.Run("put without the headquarter flag", withCleanup(func(t *testing.T) {
+		rec := send("PUT", "/BPKOPLPWXXX", `{"bankName": "PKO BP"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "bank unit with branch code XXX must be a headquarter", errMsg.Message)
+
+		bankUnit, err := bankUnitRepo.GetBySwiftCode(context.Background(), Must(model.NewSwiftCode("BPKOPLPWXXX")), repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "PKO BANK POLSKI S.A.", bankUnit.Name)
+		assert.True(t, bankUnit.IsHeadquarter)
+	}))
+
+	t.Run("invalid updates", withCleanup(func(t *testing.T) {
+		tests := []struct {
+			name    string
+			method  string
+			url     string
+			body    string
+			status  int
+			message string
+		}{
+			{"not found", "PUT", "/AAAAPLPWXXX", `{"bankName": "X"}`, http.StatusNotFound, "not found"},
+			{"patch not found", "PATCH", "/AAAAPLPWXXX", `{}`, http.StatusNotFound, "not found"},
+			{"invalid path", "PATCH", "/AAA", `{}`, http.StatusBadRequest, "swift code length must be 8 or 11 characters"},
+			{"invalid json", "PUT", "/BPKOPLPWGDG", `{`, http.StatusBadRequest, "invalid json data"},
+			{"change swift code", "PATCH", "/BPKOPLPWGDG", `{"swiftCode": "BPKOPLPWGDA"}`,
+				http.StatusBadRequest, "swift code cannot be changed"},
+			{"change country code", "PATCH", "/BEFNBGS1XXX", `{"countryISO2": "PL"}`,
+				http.StatusBadRequest, "country cannot be changed"},
+			{"change country name", "PUT", "/BEFNBGS1XXX", `{"bankName": "X", "countryName": "ROMANIA", "isHeadquarter": true}`,
+				http.StatusBadRequest, "country cannot be changed"},
+			{"empty name", "PUT", "/BPKOPLPWGDG", `{"address": "GDYNIA"}`, http.StatusBadRequest, "name cannot be empty"},
+			{"branch as headquarter", "PATCH", "/BPKOPLPWGDG", `{"isHeadquarter": true}`,
+				http.StatusBadRequest, "headquarter must have branch code XXX"},
+			{"demote headquarter", "PATCH", "/BEFNBGS1XXX", `{"isHeadquarter": false}`,
+				http.StatusBadRequest, "bank unit with branch code XXX must be a headquarter"},
+			{"invalid time zone", "PATCH", "/BPKOPLPWGDG", `{"timeZone": "Mars/Olympus"}`,
+				http.StatusBadRequest, "time zone must be a valid IANA time zone name"},
+		}
+
+		for _, tt := range tests {
+			rec := send(tt.method, tt.url, tt.body)
+
+			assert.Equal(t, tt.status, rec.Code, tt.name)
+			errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.message, errMsg.Message, tt.name)
+		}
+
+		bankUnit, err := bankUnitRepo.GetBySwiftCode(context.Background(), Must(model.NewSwiftCode("BPKOPLPWGDG")), repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "SWIETOJANSKA 17  GDYNIA, POMORSKIE, 71-368", bankUnit.Address)
+		assert.False(t, bankUnit.IsHeadquarter)
+	}))
+}
+
 func TestDeleteBankUnit(t *testing.T) {
 	r := mux.NewRouter()
 	r.HandleFunc("/{swiftCode}", handlers.DeleteBankUnit(bankUnitRepo)).Methods("DELETE")
 
 	t.Run("delete existing bank unit", withCleanup(func(t *testing.T) {
-		assert.Equal(t, 4, len(Must(bankUnitRepo.GetAll(context.Background()))))
+		assert.Equal(t, 4, len(Must(bankUnitRepo.GetAll(context.Background(), repo.ReadOptions{}))))
 
 		req := httptest.NewRequest("DELETE", "/BEFNBGS1XXX", nil)
 		rec := httptest.NewRecorder()
@@ -234,21 +583,84 @@ func TestDeleteBankUnit(t *testing.T) {
 		assert.Equal(t, "bank unit deleted", resp.Message)
 
 		swiftcode := Must(model.NewSwiftCode("BEFNBGS1XXX"))
-		_, err = bankUnitRepo.GetBySwiftCode(context.Background(), swiftcode)
+		_, err = bankUnitRepo.GetBySwiftCode(context.Background(), swiftcode, repo.ReadOptions{})
+		assert.ErrorIs(t, err, repo.ErrNotFound)
+	}))
+
+	t.Run("delete by BIC8", withCleanup(func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/BEFNBGS1", nil)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		swiftcode := Must(model.NewSwiftCode("BEFNBGS1XXX"))
+		_, err := bankUnitRepo.GetBySwiftCode(context.Background(), swiftcode, repo.ReadOptions{})
 		assert.ErrorIs(t, err, repo.ErrNotFound)
 	}))
 
 	t.Run("delete non-existing bank unit", withCleanup(func(t *testing.T) {
-		assert.Equal(t, 4, len(Must(bankUnitRepo.GetAll(context.Background()))))
+		assert.Equal(t, 4, len(Must(bankUnitRepo.GetAll(context.Background(), repo.ReadOptions{}))))
 
 		req := httptest.NewRequest("DELETE", "/NOTEXISTXXX", nil)
 		rec := httptest.NewRecorder()
 
 		r.ServeHTTP(rec, req)
 
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "not found", errMsg.Message)
+	}))
+
+	t.Run("delete headquarters with branches", withCleanup(func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/BPKOPLPWXXX", nil)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "headquarters still has branches, use cascade=true to delete them too", errMsg.Message)
+		assert.Equal(t, 4, len(Must(bankUnitRepo.GetAll(context.Background(), repo.ReadOptions{}))))
+	}))
+
+	t.Run("delete headquarters with cascade", withCleanup(func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/BPKOPLPWXXX?cascade=true", nil)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
 		assert.Equal(t, http.StatusOK, rec.Code)
+		remaining := Must(bankUnitRepo.GetAll(context.Background(), repo.ReadOptions{}))
+		assert.Len(t, remaining, 1)
+		assert.Equal(t, "BEFNBGS1XXX", remaining[0].SwiftCode.String())
 	}))
 
+	t.Run("delete branch does not need cascade", withCleanup(func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/BPKOPLPWGDG", nil)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 3, len(Must(bankUnitRepo.GetAll(context.Background(), repo.ReadOptions{}))))
+	}))
+
+	t.Run("invalid cascade value", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/BPKOPLPWXXX?cascade=yes", nil)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "cascade must be a boolean", errMsg.Message)
+	})
+
 	t.Run("invalid swift code", withCleanup(func(t *testing.T) {
 
 		req := httptest.NewRequest("DELETE", "/INVALID", nil)
@@ -259,10 +671,200 @@ func TestDeleteBankUnit(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
 		assert.Nil(t, err)
-		assert.Equal(t, "swift code length must be 11 characters", errMsg.Message)
+		assert.Equal(t, "swift code length must be 8 or 11 characters", errMsg.Message)
 	}))
 }
 
+func TestSoftDeletedBankUnits(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/deleted", handlers.GetDeletedBankUnits(bankUnitRepo)).Methods("GET")
+	r.HandleFunc("/{swiftCode}/restore", handlers.RestoreBankUnit(bankUnitRepo)).Methods("POST")
+	r.HandleFunc("/{swiftCode}", handlers.GetBankUnit(bankUnitRepo)).Methods("GET")
+	r.HandleFunc("/{swiftCode}", handlers.DeleteBankUnit(bankUnitRepo)).Methods("DELETE")
+	r.HandleFunc("/", handlers.CreateBankUnit(bankUnitRepo, countryRepo)).Methods("POST")
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("deleted bank unit is hidden unless requested", withCleanup(func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("DELETE", "/BEFNBGS1XXX", "").Code)
+
+		assert.Equal(t, http.StatusNotFound, serve("GET", "/BEFNBGS1XXX", "").Code)
+
+		rec := serve("GET", "/BEFNBGS1XXX?includeDeleted=true", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		resp, err := handlers.Decode[handlers.BranchDTO](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "BEFNBGS1XXX", resp.SwiftCode)
+		assert.NotNil(t, resp.DeletedAt)
+	}))
+
+	t.Run("invalid includeDeleted value", func(t *testing.T) {
+		rec := serve("GET", "/BEFNBGS1XXX?includeDeleted=yes", "")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "includeDeleted must be a boolean", errMsg.Message)
+	})
+
+	t.Run("list deleted bank units", withCleanup(func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("DELETE", "/BPKOPLPWXXX?cascade=true", "").Code)
+
+		rec := serve("GET", "/deleted", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		resp, err := handlers.Decode[handlers.DeletedBankUnitsResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Len(t, resp.SwiftCodes, 3)
+
+		rec = serve("GET", "/deleted?limit=1", "")
+		resp, err = handlers.Decode[handlers.DeletedBankUnitsResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Len(t, resp.SwiftCodes, 1)
+
+		rec = serve("GET", "/deleted?since=2999-01-01T00:00:00Z", "")
+		resp, err = handlers.Decode[handlers.DeletedBankUnitsResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Empty(t, resp.SwiftCodes)
+	}))
+
+	t.Run("invalid since", func(t *testing.T) {
+		rec := serve("GET", "/deleted?since=yesterday", "")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "since must be an RFC 3339 timestamp", errMsg.Message)
+	})
+
+	t.Run("restore deleted bank unit", withCleanup(func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("DELETE", "/BEFNBGS1XXX", "").Code)
+
+		rec := serve("POST", "/BEFNBGS1XXX/restore", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		resp, err := handlers.Decode[handlers.BranchDTO](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "BEFNBGS1XXX", resp.SwiftCode)
+		assert.Nil(t, resp.DeletedAt)
+
+		assert.Equal(t, http.StatusOK, serve("GET", "/BEFNBGS1XXX", "").Code)
+	}))
+
+	t.Run("restore bank unit that is not deleted", func(t *testing.T) {
+		rec := serve("POST", "/BEFNBGS1XXX/restore", "")
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "no deleted bank unit with this swift code", errMsg.Message)
+	})
+
+	t.Run("restore after the swift code was created again", withCleanup(func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("DELETE", "/BEFNBGS1XXX", "").Code)
+		body := `{
+			"address": "SOFIA",
+			"bankName": "FIRST INVESTMENT BANK",
+			"countryISO2": "BG",
+			"countryName": "BULGARIA",
+			"isHeadquarter": true,
+			"swiftCode": "BEFNBGS1XXX"
+		}`
+		assert.Equal(t, http.StatusCreated, serve("POST", "/", body).Code)
+
+		rec := serve("POST", "/BEFNBGS1XXX/restore", "")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "swift code was created again after it had been deleted", errMsg.Message)
+	}))
+}
+
+func TestPointInTimeQueries(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/country/{countryISO2code}", handlers.GetAllBankUnitsForCountry(bankUnitRepo, countryRepo)).Methods("GET")
+	r.HandleFunc("/{swiftCode}", handlers.GetBankUnit(bankUnitRepo)).Methods("GET")
+	r.HandleFunc("/{swiftCode}", handlers.PatchBankUnit(bankUnitRepo)).Methods("PATCH")
+	r.HandleFunc("/{swiftCode}", handlers.DeleteBankUnit(bankUnitRepo)).Methods("DELETE")
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	getBankUnit := func(t *testing.T, target string) handlers.BranchDTO {
+		rec := serve("GET", target, "")
+		assert.Equal(t, http.StatusOK, rec.Code, target)
+		resp, err := handlers.Decode[handlers.BranchDTO](rec.Result().Body)
+		assert.Nil(t, err)
+		return resp
+	}
+
+	// asOf formats t for the asOf query parameter, shifted by the given number of microseconds
+	asOf := func(t time.Time, shift int) string {
+		return t.Add(time.Duration(shift) * time.Microsecond).UTC().Format(time.RFC3339Nano)
+	}
+
+	t.Run("updates keep the previous version", withCleanup(func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("PATCH", "/BEFNBGS1XXX", `{"bankName": "FIBANK"}`).Code)
+
+		current := getBankUnit(t, "/BEFNBGS1XXX")
+		assert.Equal(t, "FIBANK", current.Name)
+		assert.NotNil(t, current.ValidFrom)
+		assert.Nil(t, current.ValidTo)
+
+		before := getBankUnit(t, "/BEFNBGS1XXX?asOf="+asOf(*current.ValidFrom, -1))
+		assert.NotEqual(t, "FIBANK", before.Name)
+		assert.Equal(t, current.ValidFrom.UnixMicro(), before.ValidTo.UnixMicro())
+
+		after := getBankUnit(t, "/BEFNBGS1XXX?asOf="+asOf(*current.ValidFrom, 0))
+		assert.Equal(t, "FIBANK", after.Name)
+	}))
+
+	t.Run("deleted bank unit exists before its deletion", withCleanup(func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("DELETE", "/BPKOPLPWXXX?cascade=true", "").Code)
+		deleted := getBankUnit(t, "/BPKOPLPWXXX?includeDeleted=true")
+
+		before := getBankUnit(t, "/BPKOPLPWXXX?asOf="+asOf(*deleted.DeletedAt, -1))
+		assert.Nil(t, before.DeletedAt)
+
+		assert.Equal(t, http.StatusNotFound, serve("GET", "/BPKOPLPWXXX?asOf="+asOf(*deleted.DeletedAt, 0), "").Code)
+
+		rec := serve("GET", "/country/PL?asOf="+asOf(*deleted.DeletedAt, -1), "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		response, err := handlers.Decode[handlers.SwiftCodeForCountryResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Len(t, response.SwiftCodes, 3)
+
+		rec = serve("GET", "/country/PL", "")
+		response, err = handlers.Decode[handlers.SwiftCodeForCountryResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Empty(t, response.SwiftCodes)
+	}))
+
+	t.Run("bank unit did not exist yet", func(t *testing.T) {
+		rec := serve("GET", "/BEFNBGS1XXX?asOf=2000-01-01T00:00:00Z", "")
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("invalid asOf", func(t *testing.T) {
+		for _, target := range []string{"/BEFNBGS1XXX?asOf=yesterday", "/country/BG?asOf=2026-03-01"} {
+			rec := serve("GET", target, "")
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+			errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+			assert.Nil(t, err)
+			assert.Equal(t, "asOf must be an RFC 3339 timestamp", errMsg.Message)
+		}
+	})
+}
+
 func TestCreateBankUnit(t *testing.T) {
 	r := mux.NewRouter()
 	r.HandleFunc("/", handlers.CreateBankUnit(bankUnitRepo, countryRepo)).Methods("POST")
@@ -289,11 +891,60 @@ func TestCreateBankUnit(t *testing.T) {
 
 		Must(model.NewSwiftCode("DEUTDEFFXXX"))
 		swiftcode := Must(model.NewSwiftCode("DEUTDEFFXXX"))
-		bankUnit, err := bankUnitRepo.GetBySwiftCode(context.Background(), swiftcode)
+		bankUnit, err := bankUnitRepo.GetBySwiftCode(context.Background(), swiftcode, repo.ReadOptions{})
 		assert.NoError(t, err)
 		assert.Equal(t, "DEUTDEFFXXX", bankUnit.SwiftCode.String())
 	}))
 
+	t.Run("create with directory details", withCleanup(func(t *testing.T) {
+		body := strings.NewReader(`{
+			"swiftCode": "DEUTDEFFXXX",
+			"countryISO2": "DE",
+			"countryName": "GERMANY",
+			"address": "TAUNUSANLAGE 12 FRANKFURT AM MAIN, HESSEN, 60325",
+			"bankName": "DEUTSCHE BANK AG",
+			"isHeadquarter": true,
+			"codeType": "BIC11",
+			"townName": "FRANKFURT AM MAIN",
+			"timeZone": "Europe/Berlin"
+		}`)
+
+		req := httptest.NewRequest("POST", "/", body)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		swiftcode := Must(model.NewSwiftCode("DEUTDEFFXXX"))
+		bankUnit, err := bankUnitRepo.GetBySwiftCode(context.Background(), swiftcode, repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "BIC11", bankUnit.CodeType)
+		assert.Equal(t, "FRANKFURT AM MAIN", bankUnit.TownName)
+		assert.Equal(t, "Europe/Berlin", bankUnit.TimeZone)
+	}))
+
+	t.Run("create with invalid time zone", withCleanup(func(t *testing.T) {
+		body := strings.NewReader(`{
+			"swiftCode": "DEUTDEFFXXX",
+			"countryISO2": "DE",
+			"countryName": "GERMANY",
+			"address": "TAUNUSANLAGE 12 FRANKFURT AM MAIN, HESSEN, 60325",
+			"bankName": "DEUTSCHE BANK AG",
+			"isHeadquarter": true,
+			"timeZone": "Europe/Frankfurt"
+		}`)
+
+		req := httptest.NewRequest("POST", "/", body)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, model.ErrInvalidTimeZone.Error(), errMsg.Message)
+	}))
+
 	t.Run("create duplicate bank unit", withCleanup(func(t *testing.T) {
 
 		body := strings.NewReader(`{
@@ -0,0 +1,525 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/pkarmon/swiftcodes/internal/csvimport"
+	"github.com/pkarmon/swiftcodes/internal/csvmapper"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+const maxBulkItems = 10000
+
+const (
+	// BulkModeAtomic applies either all entries or none of them.
+	BulkModeAtomic = "atomic"
+	// BulkModeBestEffort applies every valid entry and reports the rest.
+	BulkModeBestEffort = "best-effort"
+)
+
+const (
+	BulkStatusCreated   = "created"
+	BulkStatusDeleted   = "deleted"
+	BulkStatusInvalid   = "invalid"
+	BulkStatusDuplicate = "duplicate"
+	BulkStatusNotFound  = "not_found"
+	// BulkStatusHasBranches marks headquarters that were not deleted because of their branches.
+	BulkStatusHasBranches = "has_branches"
+	// BulkStatusSkipped marks valid entries that were not applied because
+	// another entry failed in atomic mode.
+	BulkStatusSkipped = "skipped"
+)
+
+var bulkStatusMessages = map[string]string{
+	BulkStatusNotFound:    "not found",
+	BulkStatusHasBranches: "headquarters still has branches, use cascade=true to delete them too",
+}
+
+var (
+	errNoBulkItems      = errors.New("no entries provided")
+	errTooManyBulkItems = fmt.Errorf("at most %d entries can be sent at once", maxBulkItems)
+)
+
+type BulkItemResult struct {
+	// Index is the position of the entry in the JSON array or the CSV file, starting at 0.
+	Index     int    `json:"index"`
+	SwiftCode string `json:"swiftCode"`
+	Status    string `json:"status"`
+	Message   string `json:"message,omitempty"`
+}
+
+type BulkResponse struct {
+	Mode    string            `json:"mode"`
+	Results []*BulkItemResult `json:"results"`
+}
+
+// BulkCreateBankUnits creates many bank units from a JSON array of entries
+// or from a CSV file with the SWIFT directory columns.
+func BulkCreateBankUnits(bankRepo repo.BankUnit, countryRepo repo.Country) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mode, err := parseBulkMode(r)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		units, results, err := readBulkCreateItems(r)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := validateBulkCreate(r.Context(), bankRepo, countryRepo, units, results); err != nil {
+			SendServerError(w)
+			return
+		}
+
+		if mode == BulkModeAtomic {
+			if status := failureStatus(results); status != 0 {
+				markPending(results, BulkStatusSkipped)
+				Encode(w, status, &BulkResponse{Mode: mode, Results: results})
+				return
+			}
+
+			err := bankRepo.BulkCreate(r.Context(), units)
+			if errors.Is(err, repo.ErrDuplicate) {
+				SendErrorMsg(w, http.StatusConflict, "duplicate swift code")
+				return
+			}
+			if err != nil {
+				SendServerError(w)
+				return
+			}
+
+			markPending(results, BulkStatusCreated)
+			Encode(w, http.StatusCreated, &BulkResponse{Mode: mode, Results: results})
+			return
+		}
+
+		for i, bu := range units {
+			if results[i].Status != "" {
+				continue
+			}
+			err := bankRepo.Create(r.Context(), bu)
+			if errors.Is(err, repo.ErrDuplicate) {
+				results[i].Status = BulkStatusDuplicate
+				results[i].Message = "duplicate swift code"
+				continue
+			}
+			if err != nil {
+				SendServerError(w)
+				return
+			}
+			results[i].Status = BulkStatusCreated
+		}
+
+		Encode(w, bestEffortStatus(results, BulkStatusCreated, http.StatusCreated),
+			&BulkResponse{Mode: mode, Results: results})
+	}
+}
+
+// BulkDeleteBankUnits deletes many bank units given as a JSON array of swift codes
+// or as a CSV file with a SWIFT CODE column. Headquarters with branches that are not
+// deleted in the same request are refused, unless cascade=true is given.
+func BulkDeleteBankUnits(bankRepo repo.BankUnit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mode, err := parseBulkMode(r)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		cascade, err := parseBoolQuery(r, "cascade")
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		codes, results, err := readBulkDeleteItems(r)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		plan, err := planBulkDelete(r.Context(), bankRepo, codes, results, cascade)
+		if err != nil {
+			SendServerError(w)
+			return
+		}
+
+		if mode == BulkModeAtomic {
+			if status := failureStatus(results); status != 0 {
+				markPending(results, BulkStatusSkipped)
+				Encode(w, status, &BulkResponse{Mode: mode, Results: results})
+				return
+			}
+
+			changes := repo.Changeset{Delete: append(plan.branches, plan.headquarters...)}
+			if err := bankRepo.ApplyChangeset(r.Context(), changes); err != nil {
+				SendServerError(w)
+				return
+			}
+
+			markPending(results, BulkStatusDeleted)
+			Encode(w, http.StatusOK, &BulkResponse{Mode: mode, Results: results})
+			return
+		}
+
+		// branches go first, so that headquarters deleted together with all their
+		// branches are no longer protected
+		statusByCode := map[string]string{}
+		for _, code := range append(plan.branches, plan.headquarters...) {
+			err := bankRepo.Delete(r.Context(), code, repo.DeleteOptions{Cascade: cascade})
+			switch {
+			case errors.Is(err, repo.ErrNotFound):
+				statusByCode[code.String()] = BulkStatusNotFound
+			case errors.Is(err, repo.ErrHasBranches):
+				statusByCode[code.String()] = BulkStatusHasBranches
+			case err != nil:
+				SendServerError(w)
+				return
+			default:
+				statusByCode[code.String()] = BulkStatusDeleted
+			}
+		}
+		for i, result := range results {
+			if result.Status == "" {
+				result.Status = statusByCode[codes[i].String()]
+				result.Message = bulkStatusMessages[result.Status]
+			}
+		}
+
+		Encode(w, bestEffortStatus(results, BulkStatusDeleted, http.StatusOK),
+			&BulkResponse{Mode: mode, Results: results})
+	}
+}
+
+func parseBulkMode(r *http.Request) (string, error) {
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", BulkModeAtomic:
+		return BulkModeAtomic, nil
+	case BulkModeBestEffort:
+		return BulkModeBestEffort, nil
+	default:
+		return "", fmt.Errorf("mode must be %s or %s", BulkModeAtomic, BulkModeBestEffort)
+	}
+}
+
+// bulkCSVSource returns the uploaded CSV file, sent either as the request body with
+// the text/csv content type or as the file field of a multipart form.
+// It returns nil when the request holds JSON.
+func bulkCSVSource(r *http.Request) (io.ReadCloser, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return r.Body, nil
+	case "multipart/form-data":
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, errors.New("multipart form must contain a csv file in the file field")
+		}
+		return file, nil
+	default:
+		return nil, nil
+	}
+}
+
+// readBulkCreateItems returns the entries of the request together with their results.
+// Entries that fail validation have a nil bank unit and a result with the invalid status,
+// the results of valid entries have no status yet.
+func readBulkCreateItems(r *http.Request) ([]*model.BankUnit, []*BulkItemResult, error) {
+	csvSrc, err := bulkCSVSource(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		units   []*model.BankUnit
+		results []*BulkItemResult
+	)
+	add := func(swiftCode string, bu *model.BankUnit, err error) {
+		result := &BulkItemResult{Index: len(results), SwiftCode: swiftCode}
+		if err != nil {
+			result.Status = BulkStatusInvalid
+			result.Message = err.Error()
+		}
+		units = append(units, bu)
+		results = append(results, result)
+	}
+
+	if csvSrc != nil {
+		defer csvSrc.Close()
+		for bu, err := range csvimport.ReadBankUnits(csvSrc) {
+			var rowErr *csvmapper.RowError
+			switch {
+			case errors.As(err, &rowErr):
+				add("", nil, rowErr.Err)
+			case err != nil:
+				return nil, nil, err
+			default:
+				add(bu.SwiftCode.String(), bu, nil)
+			}
+			if len(results) > maxBulkItems {
+				return nil, nil, errTooManyBulkItems
+			}
+		}
+	} else {
+		data, err := Decode[[]BranchDTO](r.Body)
+		if err != nil {
+			return nil, nil, errors.New("invalid json data")
+		}
+		if len(data) > maxBulkItems {
+			return nil, nil, errTooManyBulkItems
+		}
+		for i := range data {
+			bu, err := bankUnitFromDTO(&data[i])
+			add(data[i].SwiftCode, bu, err)
+		}
+	}
+
+	if len(results) == 0 {
+		return nil, nil, errNoBulkItems
+	}
+	return units, results, nil
+}
+
+// validateBulkCreate checks that the countries of the bank units exist and that
+// their swift codes are not taken, neither in the database nor by earlier entries.
+func validateBulkCreate(
+	ctx context.Context,
+	bankRepo repo.BankUnit,
+	countryRepo repo.Country,
+	units []*model.BankUnit,
+	results []*BulkItemResult,
+) error {
+	countryExists := map[model.Country]bool{}
+	seen := map[string]bool{}
+	var codes []model.SwiftCode
+	for i, bu := range units {
+		if results[i].Status != "" {
+			continue
+		}
+
+		exists, ok := countryExists[bu.Country]
+		if !ok {
+			var err error
+			exists, err = countryRepo.Exists(ctx, bu.Country)
+			if err != nil {
+				return err
+			}
+			countryExists[bu.Country] = exists
+		}
+		if !exists {
+			results[i].Status = BulkStatusInvalid
+			results[i].Message = "country does not exist, make sure ISO2 code is matching with the name"
+			continue
+		}
+
+		if seen[bu.SwiftCode.String()] {
+			results[i].Status = BulkStatusDuplicate
+			results[i].Message = "swift code appears more than once in the request"
+			continue
+		}
+		seen[bu.SwiftCode.String()] = true
+		codes = append(codes, bu.SwiftCode)
+	}
+
+	existing, err := bankRepo.GetBySwiftCodes(ctx, codes)
+	if err != nil {
+		return err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, bu := range existing {
+		taken[bu.SwiftCode.String()] = true
+	}
+	for i, bu := range units {
+		if results[i].Status == "" && taken[bu.SwiftCode.String()] {
+			results[i].Status = BulkStatusDuplicate
+			results[i].Message = "duplicate swift code"
+		}
+	}
+
+	return nil
+}
+
+func readBulkDeleteItems(r *http.Request) ([]model.SwiftCode, []*BulkItemResult, error) {
+	csvSrc, err := bulkCSVSource(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		codes   []model.SwiftCode
+		results []*BulkItemResult
+	)
+	add := func(swiftCode string, code model.SwiftCode, err error) {
+		result := &BulkItemResult{Index: len(results), SwiftCode: swiftCode}
+		if err != nil {
+			result.Status = BulkStatusInvalid
+			result.Message = err.Error()
+		}
+		codes = append(codes, code)
+		results = append(results, result)
+	}
+
+	if csvSrc != nil {
+		defer csvSrc.Close()
+		mapper := csvmapper.New(csvSrc, []string{"SWIFT CODE"}, func(record []string) (model.SwiftCode, error) {
+			return model.NewSwiftCode(record[0])
+		})
+		for code, err := range mapper.All() {
+			var rowErr *csvmapper.RowError
+			switch {
+			case errors.As(err, &rowErr):
+				add("", model.SwiftCode{}, rowErr.Err)
+			case err != nil:
+				return nil, nil, err
+			default:
+				add(code.String(), code, nil)
+			}
+			if len(results) > maxBulkItems {
+				return nil, nil, errTooManyBulkItems
+			}
+		}
+	} else {
+		data, err := Decode[[]string](r.Body)
+		if err != nil {
+			return nil, nil, errors.New("invalid json data")
+		}
+		if len(data) > maxBulkItems {
+			return nil, nil, errTooManyBulkItems
+		}
+		for _, sc := range data {
+			code, err := model.NewSwiftCode(sc)
+			add(sc, code, err)
+		}
+	}
+
+	if len(results) == 0 {
+		return nil, nil, errNoBulkItems
+	}
+	return codes, results, nil
+}
+
+// bulkDeletePlan lists the distinct swift codes that passed validation. Branches that are
+// deleted only because their headquarters is deleted with cascade are included as well.
+type bulkDeletePlan struct {
+	branches     []model.SwiftCode
+	headquarters []model.SwiftCode
+}
+
+// planBulkDelete marks entries that do not exist and, unless cascade is set, headquarters
+// with branches that are not deleted in the same request.
+func planBulkDelete(
+	ctx context.Context,
+	bankRepo repo.BankUnit,
+	codes []model.SwiftCode,
+	results []*BulkItemResult,
+	cascade bool,
+) (*bulkDeletePlan, error) {
+	var valid []model.SwiftCode
+	for i, code := range codes {
+		if results[i].Status == "" {
+			valid = append(valid, code)
+		}
+	}
+
+	existing, err := bankRepo.GetBySwiftCodes(ctx, valid)
+	if err != nil {
+		return nil, err
+	}
+	requested := make(map[string]bool, len(existing))
+	plan := &bulkDeletePlan{}
+	for _, bu := range existing {
+		requested[bu.SwiftCode.String()] = true
+		if bu.IsHeadquarter {
+			plan.headquarters = append(plan.headquarters, bu.SwiftCode)
+		} else {
+			plan.branches = append(plan.branches, bu.SwiftCode)
+		}
+	}
+	for i, code := range codes {
+		if results[i].Status == "" && !requested[code.String()] {
+			results[i].Status = BulkStatusNotFound
+			results[i].Message = bulkStatusMessages[BulkStatusNotFound]
+		}
+	}
+
+	if len(plan.headquarters) == 0 {
+		return plan, nil
+	}
+	branches, err := bankRepo.GetBranchesOf(ctx, plan.headquarters)
+	if err != nil {
+		return nil, err
+	}
+	protected := map[string]bool{}
+	for _, branch := range branches {
+		if requested[branch.SwiftCode.String()] {
+			continue
+		}
+		if cascade {
+			plan.branches = append(plan.branches, branch.SwiftCode)
+		} else {
+			protected[branch.SwiftCode.BaseCode()] = true
+		}
+	}
+	for i, code := range codes {
+		if results[i].Status == "" && code.HasHeadQuartersBranchCode() && protected[code.BaseCode()] {
+			results[i].Status = BulkStatusHasBranches
+			results[i].Message = bulkStatusMessages[BulkStatusHasBranches]
+		}
+	}
+	deletable := plan.headquarters[:0]
+	for _, hq := range plan.headquarters {
+		if !protected[hq.BaseCode()] {
+			deletable = append(deletable, hq)
+		}
+	}
+	plan.headquarters = deletable
+
+	return plan, nil
+}
+
+// failureStatus returns the response status of an atomic request that cannot be applied,
+// or 0 when every entry is valid. Invalid entries take precedence over conflicts.
+func failureStatus(results []*BulkItemResult) int {
+	status := 0
+	for _, result := range results {
+		switch result.Status {
+		case BulkStatusInvalid:
+			return http.StatusBadRequest
+		case BulkStatusDuplicate, BulkStatusHasBranches:
+			status = http.StatusConflict
+		case BulkStatusNotFound:
+			if status == 0 {
+				status = http.StatusNotFound
+			}
+		}
+	}
+	return status
+}
+
+// markPending sets the status of entries that passed validation.
+func markPending(results []*BulkItemResult, status string) {
+	for _, result := range results {
+		if result.Status == "" {
+			result.Status = status
+		}
+	}
+}
+
+// bestEffortStatus returns okStatus when every entry succeeded and 207 Multi-Status otherwise.
+func bestEffortStatus(results []*BulkItemResult, success string, okStatus int) int {
+	for _, result := range results {
+		if result.Status != success {
+			return http.StatusMultiStatus
+		}
+	}
+	return okStatus
+}
@@ -0,0 +1,318 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkarmon/swiftcodes/internal/handlers"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkCreateBankUnits(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/bulk", handlers.BulkCreateBankUnits(bankUnitRepo, countryRepo)).Methods("POST")
+
+	send := func(t *testing.T, url, contentType string, body string) (int, handlers.BulkResponse) {
+		req := httptest.NewRequest("POST", url, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		response, _ := handlers.Decode[handlers.BulkResponse](rec.Result().Body)
+		return rec.Code, response
+	}
+
+	statuses := func(response handlers.BulkResponse) []string {
+		var s []string
+		for _, result := range response.Results {
+			s = append(s, result.Status)
+		}
+		return s
+	}
+
+	exists := func(t *testing.T, code string) bool {
+		_, err := bankUnitRepo.GetBySwiftCode(context.Background(), Must(model.NewSwiftCode(code)), repo.ReadOptions{})
+		return err == nil
+	}
+
+	validEntries := `[
+		{"swiftCode": "DEUTDEFFXXX", "countryISO2": "DE", "countryName": "GERMANY",
+		 "address": "FRANKFURT", "bankName": "DEUTSCHE BANK AG", "isHeadquarter": true},
+		{"swiftCode": "DEUTDEFF500", "countryISO2": "DE", "countryName": "GERMANY",
+		 "address": "FRANKFURT", "bankName": "DEUTSCHE BANK AG", "isHeadquarter": false,
+		 "timeZone": "Europe/Berlin"}
+	]`
+
+	mixedEntries := `[
+		{"swiftCode": "DEUTDEFFXXX", "countryISO2": "DE", "countryName": "GERMANY",
+		 "address": "FRANKFURT", "bankName": "DEUTSCHE BANK AG", "isHeadquarter": true},
+		{"swiftCode": "DEUT", "countryISO2": "DE", "countryName": "GERMANY",
+		 "address": "FRANKFURT", "bankName": "DEUTSCHE BANK AG", "isHeadquarter": true},
+		{"swiftCode": "BPKOPLPWXXX", "countryISO2": "PL", "countryName": "POLAND",
+		 "address": "WARSZAWA", "bankName": "PKO BANK POLSKI S.A.", "isHeadquarter": true},
+		{"swiftCode": "DEUTDEFFXXX", "countryISO2": "DE", "countryName": "GERMANY",
+		 "address": "FRANKFURT", "bankName": "DEUTSCHE BANK AG", "isHeadquarter": true},
+		{"swiftCode": "CITIFRPPXXX", "countryISO2": "FR", "countryName": "FRANCE",
+		 "address": "PARIS", "bankName": "CITIBANK", "isHeadquarter": true}
+	]`
+
+	t.Run("atomic create", withCleanup(func(t *testing.T) {
+		status, response := send(t, "/bulk", "", validEntries)
+
+		assert.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, handlers.BulkModeAtomic, response.Mode)
+		assert.Equal(t, []string{handlers.BulkStatusCreated, handlers.BulkStatusCreated}, statuses(response))
+		assert.Equal(t, 1, response.Results[1].Index)
+		assert.Equal(t, "DEUTDEFF500", response.Results[1].SwiftCode)
+		assert.True(t, exists(t, "DEUTDEFFXXX"))
+		assert.True(t, exists(t, "DEUTDEFF500"))
+	}))
+
+	t.Run("atomic create rejects everything on failure", withCleanup(func(t *testing.T) {
+		status, response := send(t, "/bulk?mode=atomic", "application/json", mixedEntries)
+
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, []string{
+			handlers.BulkStatusSkipped,
+			handlers.BulkStatusInvalid,
+			handlers.BulkStatusDuplicate,
+			handlers.BulkStatusDuplicate,
+			handlers.BulkStatusInvalid,
+		}, statuses(response))
+		assert.Equal(t, "swift code length must be 8 or 11 characters", response.Results[1].Message)
+		assert.Equal(t, "duplicate swift code", response.Results[2].Message)
+		assert.Equal(t, "swift code appears more than once in the request", response.Results[3].Message)
+		assert.Equal(t, "country does not exist, make sure ISO2 code is matching with the name", response.Results[4].Message)
+		assert.False(t, exists(t, "DEUTDEFFXXX"))
+	}))
+
+	t.Run("atomic create with only conflicts", withCleanup(func(t *testing.T) {
+		status, response := send(t, "/bulk", "", `[
+			{"swiftCode": "BPKOPLPWXXX", "countryISO2": "PL", "countryName": "POLAND",
+			 "address": "WARSZAWA", "bankName": "PKO BANK POLSKI S.A.", "isHeadquarter": true}
+		]`)
+
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, []string{handlers.BulkStatusDuplicate}, statuses(response))
+	}))
+
+	t.Run("best effort create", withCleanup(func(t *testing.T) {
+		status, response := send(t, "/bulk?mode=best-effort", "", mixedEntries)
+
+		assert.Equal(t, http.StatusMultiStatus, status)
+		assert.Equal(t, handlers.BulkModeBestEffort, response.Mode)
+		assert.Equal(t, []string{
+			handlers.BulkStatusCreated,
+			handlers.BulkStatusInvalid,
+			handlers.BulkStatusDuplicate,
+			handlers.BulkStatusDuplicate,
+			handlers.BulkStatusInvalid,
+		}, statuses(response))
+		assert.True(t, exists(t, "DEUTDEFFXXX"))
+	}))
+
+	t.Run("best effort create without failures", withCleanup(func(t *testing.T) {
+		status, response := send(t, "/bulk?mode=best-effort", "", validEntries)
+
+		assert.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, []string{handlers.BulkStatusCreated, handlers.BulkStatusCreated}, statuses(response))
+	}))
+
+	t.Run("create from csv body", withCleanup(func(t *testing.T) {
+		csv := "SWIFT CODE,COUNTRY ISO2 CODE,COUNTRY NAME,NAME,ADDRESS,CODE TYPE,TOWN NAME,TIME ZONE\n" +
+			"DEUTDEFFXXX,DE,GERMANY,DEUTSCHE BANK AG,FRANKFURT,BIC11,FRANKFURT,Europe/Berlin\n" +
+			"DEUTDEFF500,DE,GERMANY,DEUTSCHE BANK AG,FRANKFURT,BIC11,FRANKFURT,Europe/Frankfurt\n"
+
+		status, response := send(t, "/bulk?mode=best-effort", "text/csv", csv)
+
+		assert.Equal(t, http.StatusMultiStatus, status)
+		assert.Equal(t, []string{handlers.BulkStatusCreated, handlers.BulkStatusInvalid}, statuses(response))
+		assert.Contains(t, response.Results[1].Message, "time zone must be a valid IANA time zone name")
+
+		bu, err := bankUnitRepo.GetBySwiftCode(context.Background(), Must(model.NewSwiftCode("DEUTDEFFXXX")), repo.ReadOptions{})
+		assert.NoError(t, err)
+		assert.True(t, bu.IsHeadquarter)
+		assert.Equal(t, "Europe/Berlin", bu.TimeZone)
+	}))
+
+	t.Run("create from uploaded csv file", withCleanup(func(t *testing.T) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		file := Must(form.CreateFormFile("file", "banks.csv"))
+		_, err := file.Write([]byte("SWIFT CODE,COUNTRY ISO2 CODE,COUNTRY NAME,NAME,ADDRESS,CODE TYPE,TOWN NAME,TIME ZONE\n" +
+			"DEUTDEFFXXX,DE,GERMANY,DEUTSCHE BANK AG,FRANKFURT,BIC11,FRANKFURT,Europe/Berlin\n"))
+		assert.NoError(t, err)
+		assert.NoError(t, form.Close())
+
+		status, response := send(t, "/bulk", form.FormDataContentType(), body.String())
+
+		assert.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, []string{handlers.BulkStatusCreated}, statuses(response))
+		assert.True(t, exists(t, "DEUTDEFFXXX"))
+	}))
+
+	t.Run("invalid requests", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			url         string
+			contentType string
+			body        string
+			message     string
+		}{
+			{"unknown mode", "/bulk?mode=some", "", validEntries, "mode must be atomic or best-effort"},
+			{"empty array", "/bulk", "", "[]", "no entries provided"},
+			{"not an array", "/bulk", "", "{}", "invalid json data"},
+			{"csv header mismatch", "/bulk", "text/csv", "SWIFT CODE\nDEUTDEFFXXX\n", "header does not match expected columns"},
+			{"multipart without file", "/bulk", "multipart/form-data; boundary=x", "--x--\r\n",
+				"multipart form must contain a csv file in the file field"},
+		}
+
+		for _, tt := range tests {
+			req := httptest.NewRequest("POST", tt.url, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, tt.name)
+			errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.message, errMsg.Message, tt.name)
+		}
+	})
+}
+
+func TestBulkDeleteBankUnits(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/bulk/delete", handlers.BulkDeleteBankUnits(bankUnitRepo)).Methods("POST")
+
+	send := func(t *testing.T, url, contentType, body string) (int, handlers.BulkResponse) {
+		req := httptest.NewRequest("POST", url, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		response, _ := handlers.Decode[handlers.BulkResponse](rec.Result().Body)
+		return rec.Code, response
+	}
+
+	statuses := func(response handlers.BulkResponse) []string {
+		var s []string
+		for _, result := range response.Results {
+			s = append(s, result.Status)
+		}
+		return s
+	}
+
+	remaining := func(t *testing.T) int {
+		all, err := bankUnitRepo.GetAll(context.Background(), repo.ReadOptions{})
+		assert.NoError(t, err)
+		return len(all)
+	}
+
+	t.Run("atomic delete", withCleanup(func(t *testing.T) {
+		status, response := send(t, "/bulk/delete", "", `["BPKOPLPWCSD", "bpkoplpwgdg", "BPKOPLPWCSD"]`)
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{
+			handlers.BulkStatusDeleted, handlers.BulkStatusDeleted, handlers.BulkStatusDeleted,
+		}, statuses(response))
+		assert.Equal(t, 2, remaining(t))
+	}))
+
+	t.Run("atomic delete rejects everything on failure", withCleanup(func(t *testing.T) {
+		status, response := send(t, "/bulk/delete", "", `["BPKOPLPWCSD", "AAAAPLPWXXX"]`)
+
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, []string{handlers.BulkStatusSkipped, handlers.BulkStatusNotFound}, statuses(response))
+		assert.Equal(t, 4, remaining(t))
+
+		status, response = send(t, "/bulk/delete", "", `["BPKOPLPWCSD", "AAAAPLPWXXX", "BPKO"]`)
+
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, []string{
+			handlers.BulkStatusSkipped, handlers.BulkStatusNotFound, handlers.BulkStatusInvalid,
+		}, statuses(response))
+		assert.Equal(t, 4, remaining(t))
+	}))
+
+	t.Run("best effort delete", withCleanup(func(t *testing.T) {
+		status, response := send(t, "/bulk/delete?mode=best-effort", "", `["BPKOPLPWCSD", "AAAAPLPWXXX", "BPKO"]`)
+
+		assert.Equal(t, http.StatusMultiStatus, status)
+		assert.Equal(t, []string{
+			handlers.BulkStatusDeleted, handlers.BulkStatusNotFound, handlers.BulkStatusInvalid,
+		}, statuses(response))
+		assert.Equal(t, 3, remaining(t))
+	}))
+
+	t.Run("headquarters with branches are protected", withCleanup(func(t *testing.T) {
+		status, response := send(t, "/bulk/delete", "", `["BPKOPLPWXXX", "BPKOPLPWCSD"]`)
+
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, []string{handlers.BulkStatusHasBranches, handlers.BulkStatusSkipped}, statuses(response))
+		assert.Equal(t, 4, remaining(t))
+
+		status, response = send(t, "/bulk/delete?mode=best-effort", "", `["BPKOPLPWXXX", "BPKOPLPWCSD"]`)
+
+		assert.Equal(t, http.StatusMultiStatus, status)
+		assert.Equal(t, []string{handlers.BulkStatusHasBranches, handlers.BulkStatusDeleted}, statuses(response))
+		assert.Equal(t, 3, remaining(t))
+	}))
+
+	t.Run("headquarters deleted with all branches", withCleanup(func(t *testing.T) {
+		status, response := send(t, "/bulk/delete?mode=best-effort", "", `["BPKOPLPWXXX", "BPKOPLPWCSD", "BPKOPLPWGDG"]`)
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{
+			handlers.BulkStatusDeleted, handlers.BulkStatusDeleted, handlers.BulkStatusDeleted,
+		}, statuses(response))
+		assert.Equal(t, 1, remaining(t))
+	}))
+
+	t.Run("cascade deletes branches", withCleanup(func(t *testing.T) {
+		status, response := send(t, "/bulk/delete?cascade=true", "", `["BPKOPLPWXXX"]`)
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{handlers.BulkStatusDeleted}, statuses(response))
+		assert.Equal(t, 1, remaining(t))
+	}))
+
+	t.Run("delete from csv", withCleanup(func(t *testing.T) {
+		csv := "NAME,SWIFT CODE\nPKO,BPKOPLPWCSD\nPKO,BPKOPLPWGDG\n"
+
+		status, response := send(t, "/bulk/delete", "text/csv", csv)
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{handlers.BulkStatusDeleted, handlers.BulkStatusDeleted}, statuses(response))
+		_, err := bankUnitRepo.GetBySwiftCode(context.Background(), Must(model.NewSwiftCode("BPKOPLPWCSD")), repo.ReadOptions{})
+		assert.ErrorIs(t, err, repo.ErrNotFound)
+	}))
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for _, body := range []string{"[]", `{"swiftCodes": []}`} {
+			req := httptest.NewRequest("POST", "/bulk/delete", strings.NewReader(body))
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/pkarmon/swiftcodes/internal/cache"
+)
+
+type CacheStatsDTO struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+	// HitRatio is the share of reads answered from the cache, 0 before the first read.
+	HitRatio float64 `json:"hitRatio"`
+}
+
+// GetCacheStats reports how the bank unit cache has performed since the server started.
+func GetCacheStats(bankCache *cache.BankUnitRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := bankCache.Stats()
+		dto := &CacheStatsDTO{
+			Hits:      stats.Hits,
+			Misses:    stats.Misses,
+			Evictions: stats.Evictions,
+			Entries:   stats.Entries,
+		}
+		if reads := stats.Hits + stats.Misses; reads > 0 {
+			dto.HitRatio = float64(stats.Hits) / float64(reads)
+		}
+		Encode(w, http.StatusOK, dto)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func Encode[T any](w http.ResponseWriter, status int, data T) error {
@@ -50,3 +52,29 @@ func SendSuccessMsg(w http.ResponseWriter, status int, msg string) {
 func SendServerError(w http.ResponseWriter) {
 	SendErrorMsg(w, http.StatusInternalServerError, "server error")
 }
+
+// parseBoolQuery reads an optional boolean query parameter, defaulting to false.
+func parseBoolQuery(r *http.Request, name string) (bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean", name)
+	}
+	return b, nil
+}
+
+// parseTimeQuery reads an optional RFC 3339 timestamp, the zero time means it was not given.
+func parseTimeQuery(r *http.Request, name string) (time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return t, nil
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+const maxLookupSwiftCodes = 10000
+
+const (
+	LookupStatusFound    = "found"
+	LookupStatusNotFound = "not_found"
+	LookupStatusInvalid  = "invalid"
+)
+
+type LookupRequest struct {
+	SwiftCodes []string `json:"swiftCodes"`
+}
+
+type LookupResult struct {
+	// SwiftCode is the code exactly as it was requested.
+	SwiftCode string `json:"swiftCode"`
+	Status    string `json:"status"`
+	// Error explains why an invalid code was rejected.
+	Error string `json:"error,omitempty"`
+	// BankUnit is a *BranchDTO, or a *HeadquartersDTO for headquarters.
+	BankUnit any `json:"bankUnit,omitempty"`
+}
+
+type LookupResponse struct {
+	// Results are in the same order as the requested swift codes.
+	Results []*LookupResult `json:"results"`
+}
+
+// LookupBankUnits resolves many swift codes at once, with the same
+// representation as GetBankUnit for every found code.
+func LookupBankUnits(bankRepo repo.BankUnit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := Decode[LookupRequest](r.Body)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, "invalid json data")
+			return
+		}
+		if len(data.SwiftCodes) == 0 {
+			SendErrorMsg(w, http.StatusBadRequest, "swiftCodes must not be empty")
+			return
+		}
+		if len(data.SwiftCodes) > maxLookupSwiftCodes {
+			SendErrorMsg(w, http.StatusBadRequest,
+				fmt.Sprintf("at most %d swift codes can be looked up at once", maxLookupSwiftCodes))
+			return
+		}
+
+		results := make([]*LookupResult, len(data.SwiftCodes))
+		parsed := make([]model.SwiftCode, len(data.SwiftCodes))
+		var valid []model.SwiftCode
+		for i, sc := range data.SwiftCodes {
+			results[i] = &LookupResult{SwiftCode: sc}
+			swiftCode, err := model.NewSwiftCode(sc)
+			if err != nil {
+				results[i].Status = LookupStatusInvalid
+				results[i].Error = err.Error()
+				continue
+			}
+			parsed[i] = swiftCode
+			valid = append(valid, swiftCode)
+		}
+
+		found, err := bankRepo.GetBySwiftCodes(r.Context(), valid)
+		if err != nil {
+			SendServerError(w)
+			return
+		}
+
+		byCode := make(map[string]*model.BankUnit, len(found))
+		var headquarters []model.SwiftCode
+		for _, bu := range found {
+			byCode[bu.SwiftCode.String()] = bu
+			if bu.IsHeadquarter {
+				headquarters = append(headquarters, bu.SwiftCode)
+			}
+		}
+
+		branchesByBaseCode := map[string][]*model.BankUnit{}
+		if len(headquarters) > 0 {
+			branches, err := bankRepo.GetBranchesOf(r.Context(), headquarters)
+			if err != nil {
+				SendServerError(w)
+				return
+			}
+			for _, b := range branches {
+				branchesByBaseCode[b.SwiftCode.BaseCode()] = append(branchesByBaseCode[b.SwiftCode.BaseCode()], b)
+			}
+		}
+
+		for i, result := range results {
+			if result.Status == LookupStatusInvalid {
+				continue
+			}
+			bu, ok := byCode[parsed[i].String()]
+			if !ok {
+				result.Status = LookupStatusNotFound
+				continue
+			}
+
+			result.Status = LookupStatusFound
+			if bu.IsHeadquarter {
+				dto := headquartersToDTO(bu, branchesByBaseCode[bu.SwiftCode.BaseCode()])
+				dto.MatchedForm = parsed[i].Form()
+				result.BankUnit = dto
+			} else {
+				dto := branchToDTO(bu)
+				dto.MatchedForm = parsed[i].Form()
+				result.BankUnit = dto
+			}
+		}
+
+		Encode(w, http.StatusOK, &LookupResponse{Results: results})
+	}
+}
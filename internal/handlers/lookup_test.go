@@ -0,0 +1,123 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkarmon/swiftcodes/internal/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupBankUnits(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/lookup", handlers.LookupBankUnits(bankUnitRepo)).Methods("POST")
+
+	lookup := func(t *testing.T, body any) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		assert.NoError(t, err)
+		req := httptest.NewRequest("POST", "/lookup", bytes.NewReader(data))
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("mixed results keep request order", func(t *testing.T) {
+		rec := lookup(t, handlers.LookupRequest{SwiftCodes: []string{
+			"BPKOPLPWXXX", "AAAAPLPWXXX", "bpkoplpwgdg", "BPKO", "BPKOPLPW",
+		}})
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		response, err := handlers.Decode[handlers.LookupResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Len(t, response.Results, 5)
+
+		hq := response.Results[0]
+		assert.Equal(t, "BPKOPLPWXXX", hq.SwiftCode)
+		assert.Equal(t, handlers.LookupStatusFound, hq.Status)
+		hqUnit := hq.BankUnit.(map[string]any)
+		assert.Equal(t, "BPKOPLPWXXX", hqUnit["swiftCode"])
+		assert.Equal(t, "BIC11", hqUnit["matchedForm"])
+		assert.Len(t, hqUnit["branches"], 2)
+
+		assert.Equal(t, handlers.LookupStatusNotFound, response.Results[1].Status)
+		assert.Nil(t, response.Results[1].BankUnit)
+
+		branch := response.Results[2]
+		assert.Equal(t, "bpkoplpwgdg", branch.SwiftCode)
+		assert.Equal(t, handlers.LookupStatusFound, branch.Status)
+		branchUnit := branch.BankUnit.(map[string]any)
+		assert.Equal(t, "BPKOPLPWGDG", branchUnit["swiftCode"])
+		assert.NotContains(t, branchUnit, "branches")
+
+		invalid := response.Results[3]
+		assert.Equal(t, handlers.LookupStatusInvalid, invalid.Status)
+		assert.Equal(t, "swift code length must be 8 or 11 characters", invalid.Error)
+
+		bic8 := response.Results[4]
+		assert.Equal(t, handlers.LookupStatusFound, bic8.Status)
+		assert.Equal(t, "BIC8", bic8.BankUnit.(map[string]any)["matchedForm"])
+	})
+
+	t.Run("duplicates are resolved separately", func(t *testing.T) {
+		rec := lookup(t, handlers.LookupRequest{SwiftCodes: []string{"BEFNBGS1XXX", "BEFNBGS1XXX"}})
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		response, err := handlers.Decode[handlers.LookupResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Len(t, response.Results, 2)
+		for _, result := range response.Results {
+			assert.Equal(t, handlers.LookupStatusFound, result.Status)
+		}
+	})
+
+	t.Run("only invalid codes", func(t *testing.T) {
+		rec := lookup(t, handlers.LookupRequest{SwiftCodes: []string{"1234PLPWXXX"}})
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		response, err := handlers.Decode[handlers.LookupResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, handlers.LookupStatusInvalid, response.Results[0].Status)
+		assert.Equal(t, "swift code institution code must consist of 4 letters", response.Results[0].Error)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		tooMany := make([]string, 10001)
+		for i := range tooMany {
+			tooMany[i] = "BPKOPLPWXXX"
+		}
+
+		tests := []struct {
+			name    string
+			body    any
+			message string
+		}{
+			{"empty list", handlers.LookupRequest{}, "swiftCodes must not be empty"},
+			{"too many codes", handlers.LookupRequest{SwiftCodes: tooMany}, "at most 10000 swift codes can be looked up at once"},
+			{"wrong type", map[string]any{"swiftCodes": "BPKOPLPWXXX"}, "invalid json data"},
+		}
+
+		for _, tt := range tests {
+			rec := lookup(t, tt.body)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, tt.name)
+			errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.message, errMsg.Message, tt.name)
+		}
+	})
+
+	t.Run("malformed json", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/lookup", strings.NewReader("{"))
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor returns an opaque cursor pointing right after the given swift code.
+func encodeCursor(sc model.SwiftCode) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(sc.String()))
+}
+
+// decodeCursor returns the swift code the cursor points after, an empty cursor
+// means the first page.
+func decodeCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", errInvalidCursor
+	}
+	sc, err := model.NewSwiftCode(string(raw))
+	if err != nil {
+		return "", errInvalidCursor
+	}
+	return sc.String(), nil
+}
+
+// parseLimitQuery reads the optional limit query parameter, defaulting to defaultPageLimit.
+func parseLimitQuery(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultPageLimit, nil
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		return 0, fmt.Errorf("limit must be an integer between 1 and %d", maxPageLimit)
+	}
+	return limit, nil
+}
+
+// parseListQuery reads the excludeTestBICs, limit, cursor and asOf query parameters.
+// The returned options ask for one bank unit more than the page size, which tells
+// whether there is a next page (see trimPage).
+func parseListQuery(r *http.Request) (repo.ListOptions, error) {
+	excludeTestBICs, err := parseBoolQuery(r, "excludeTestBICs")
+	if err != nil {
+		return repo.ListOptions{}, err
+	}
+
+	limit, err := parseLimitQuery(r)
+	if err != nil {
+		return repo.ListOptions{}, err
+	}
+
+	after, err := decodeCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		return repo.ListOptions{}, err
+	}
+
+	asOf, err := parseTimeQuery(r, "asOf")
+	if err != nil {
+		return repo.ListOptions{}, err
+	}
+
+	return repo.ListOptions{ExcludeTestBICs: excludeTestBICs, After: after, Limit: limit + 1, AsOf: asOf}, nil
+}
+
+// trimPage drops the extra bank unit requested by parseListQuery and returns
+// the cursor of the next page, which is empty on the last page.
+func trimPage(bankUnits []*model.BankUnit, opts repo.ListOptions) ([]*model.BankUnit, string) {
+	limit := opts.Limit - 1
+	if len(bankUnits) <= limit {
+		return bankUnits, ""
+	}
+	bankUnits = bankUnits[:limit]
+	return bankUnits, encodeCursor(bankUnits[limit-1].SwiftCode)
+}
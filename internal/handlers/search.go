@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+type SearchResultDTO struct {
+	*BranchDTO
+	Score float64 `json:"score"`
+}
+
+type SearchResponse struct {
+	Results []*SearchResultDTO `json:"results"`
+}
+
+func SearchBankUnits(bankRepo repo.BankUnit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		text := strings.TrimSpace(r.URL.Query().Get("q"))
+		if text == "" {
+			SendErrorMsg(w, http.StatusBadRequest, "q is required")
+			return
+		}
+
+		query := repo.SearchQuery{Text: text}
+
+		if country := r.URL.Query().Get("country"); country != "" {
+			code, err := model.NewCountryISO2(country)
+			if err != nil {
+				SendErrorMsg(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			query.CountryISO2 = code
+		}
+
+		hqOnly, err := parseBoolQuery(r, "headquartersOnly")
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		query.HeadquartersOnly = hqOnly
+
+		query.Limit, err = parseLimitQuery(r)
+		if err != nil {
+			SendErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		results, err := bankRepo.Search(r.Context(), query)
+		if err != nil {
+			SendServerError(w)
+			return
+		}
+
+		res := SearchResponse{Results: make([]*SearchResultDTO, len(results))}
+		for i, result := range results {
+			res.Results[i] = &SearchResultDTO{
+				BranchDTO: branchToDTO(result.BankUnit),
+				Score:     result.Score,
+			}
+		}
+
+		Encode(w, http.StatusOK, &res)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkarmon/swiftcodes/internal/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchBankUnits(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/search", handlers.SearchBankUnits(bankUnitRepo)).Methods("GET")
+
+	search := func(t *testing.T, query string) handlers.SearchResponse {
+		req := httptest.NewRequest("GET", "/search?"+query, nil)
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		response, err := handlers.Decode[handlers.SearchResponse](rec.Result().Body)
+		assert.Nil(t, err)
+		return response
+	}
+
+	swiftCodes := func(response handlers.SearchResponse) []string {
+		codes := []string{}
+		for _, result := range response.Results {
+			codes = append(codes, result.SwiftCode)
+		}
+		return codes
+	}
+
+	t.Run("search by town", func(t *testing.T) {
+		response := search(t, "q=gdynia")
+
+		assert.Equal(t, []string{"BPKOPLPWGDG"}, swiftCodes(response))
+		assert.Greater(t, response.Results[0].Score, 0.0)
+		assert.Equal(t, "PKO BANK POLSKI S.A.", response.Results[0].Name)
+	})
+
+	t.Run("search ignores accents", func(t *testing.T) {
+		response := search(t, "q=%C5%9Awi%C4%99toja%C5%84ska")
+
+		assert.Equal(t, []string{"BPKOPLPWGDG"}, swiftCodes(response))
+	})
+
+	t.Run("search tolerates typos", func(t *testing.T) {
+		response := search(t, "q=warszwa")
+
+		assert.ElementsMatch(t, []string{"BPKOPLPWXXX", "BPKOPLPWCSD"}, swiftCodes(response))
+	})
+
+	t.Run("results are ordered by relevance", func(t *testing.T) {
+		response := search(t, "q=pko+warszawa")
+
+		assert.NotEmpty(t, response.Results)
+		for i := 1; i < len(response.Results); i++ {
+			assert.GreaterOrEqual(t, response.Results[i-1].Score, response.Results[i].Score)
+		}
+	})
+
+	t.Run("filter by country", func(t *testing.T) {
+		assert.Equal(t, []string{"BEFNBGS1XXX"}, swiftCodes(search(t, "q=benchmark")))
+		assert.Empty(t, search(t, "q=benchmark&country=PL").Results)
+	})
+
+	t.Run("headquarters only", func(t *testing.T) {
+		response := search(t, "q=pko&headquartersOnly=true&country=pl")
+
+		assert.Equal(t, []string{"BPKOPLPWXXX"}, swiftCodes(response))
+		assert.True(t, response.Results[0].IsHeadquarter)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		assert.Len(t, search(t, "q=pko&limit=1").Results, 1)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		tests := []struct {
+			query   string
+			message string
+		}{
+			{"", "q is required"},
+			{"q=+", "q is required"},
+			{"q=pko&country=POL", "country ISO2 code length must be 2 characters"},
+			{"q=pko&headquartersOnly=maybe", "headquartersOnly must be a boolean"},
+			{"q=pko&limit=0", "limit must be an integer between 1 and 1000"},
+		}
+
+		for _, tt := range tests {
+			req := httptest.NewRequest("GET", "/search?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, tt.query)
+			errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.message, errMsg.Message, tt.query)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+type APIKeyRepo struct {
+	store *Store
+}
+
+func NewAPIKeyRepo(store *Store) *APIKeyRepo {
+	return &APIKeyRepo{store: store}
+}
+
+func (r *APIKeyRepo) Create(_ context.Context, key *model.APIKey, secretHash string) error {
+	return r.store.write(func(d *data) error {
+		created, err := d.insertAPIKey(key, secretHash, now())
+		if err != nil {
+			return err
+		}
+		key.ID, key.CreatedAt = created.ID, created.CreatedAt
+		return nil
+	})
+}
+
+func (r *APIKeyRepo) Use(_ context.Context, secretHash string) (*model.APIKey, error) {
+	var used *model.APIKey
+	err := r.store.write(func(d *data) error {
+		at := now()
+		for i := range d.apiKeys {
+			row := d.apiKeys[i]
+			if row.secretHash == secretHash && row.key.Active(at) {
+				key := d.changeAPIKey(i)
+				key.LastUsedAt = &at
+				used = copyAPIKey(*key)
+				return nil
+			}
+		}
+		return repo.ErrNotFound
+	})
+	if err != nil {
+		return nil, err
+	}
+	return used, nil
+}
+
+func (r *APIKeyRepo) List(_ context.Context, includeRevoked bool) ([]*model.APIKey, error) {
+	var keys []*model.APIKey
+	err := r.store.read(func(d *data) error {
+		for _, row := range d.apiKeys {
+			if includeRevoked || row.key.RevokedAt == nil {
+				keys = append(keys, copyAPIKey(row.key))
+			}
+		}
+		return nil
+	})
+	// keys are stored by id, so a stable sort orders them by owner and id
+	slices.SortStableFunc(keys, func(a, b *model.APIKey) int {
+		return strings.Compare(a.Owner, b.Owner)
+	})
+	return keys, err
+}
+
+func (r *APIKeyRepo) Rotate(_ context.Context, id int64, prefix, secretHash string) (*model.APIKey, error) {
+	var rotated *model.APIKey
+	err := r.store.write(func(d *data) error {
+		at := now()
+		i := slices.IndexFunc(d.apiKeys, func(row apiKeyRow) bool {
+			return row.key.ID == id && row.key.Active(at)
+		})
+		if i < 0 {
+			return repo.ErrNotFound
+		}
+		old := d.apiKeys[i].key
+		d.changeAPIKey(i).RevokedAt = &at
+
+		var err error
+		rotated, err = d.insertAPIKey(&model.APIKey{
+			Prefix:      prefix,
+			Owner:       old.Owner,
+			Scopes:      old.Scopes,
+			ExpiresAt:   old.ExpiresAt,
+			RotatedFrom: &old.ID,
+		}, secretHash, at)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rotated, nil
+}
+
+func (r *APIKeyRepo) Revoke(_ context.Context, id int64) error {
+	return r.store.write(func(d *data) error {
+		i := slices.IndexFunc(d.apiKeys, func(row apiKeyRow) bool {
+			return row.key.ID == id && row.key.RevokedAt == nil
+		})
+		if i < 0 {
+			return repo.ErrNotFound
+		}
+		at := now()
+		d.changeAPIKey(i).RevokedAt = &at
+		return nil
+	})
+}
+
+// insertAPIKey stores a copy of the key created at the given time and returns it,
+// the secret hash must be unique.
+func (d *data) insertAPIKey(key *model.APIKey, secretHash string, at time.Time) (*model.APIKey, error) {
+	if slices.ContainsFunc(d.apiKeys, func(row apiKeyRow) bool { return row.secretHash == secretHash }) {
+		return nil, repo.ErrDuplicate
+	}
+
+	stored := *copyAPIKey(*key)
+	stored.ID = d.nextID()
+	stored.CreatedAt = at
+	stored.LastUsedAt = nil
+	stored.RevokedAt = nil
+	d.apiKeys = append(d.apiKeys, apiKeyRow{key: stored, secretHash: secretHash})
+	return copyAPIKey(stored), nil
+}
+
+// copyAPIKey copies the key deeply, so callers cannot change the stored one.
+func copyAPIKey(key model.APIKey) *model.APIKey {
+	key.Scopes = slices.Clone(key.Scopes)
+	key.ExpiresAt = copyTime(key.ExpiresAt)
+	key.LastUsedAt = copyTime(key.LastUsedAt)
+	key.RevokedAt = copyTime(key.RevokedAt)
+	if key.RotatedFrom != nil {
+		id := *key.RotatedFrom
+		key.RotatedFrom = &id
+	}
+	return &key
+}
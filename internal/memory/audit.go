@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/audit"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+type AuditRepo struct {
+	store *Store
+}
+
+func NewAuditRepo(store *Store) *AuditRepo {
+	return &AuditRepo{store: store}
+}
+
+func (r *AuditRepo) List(_ context.Context, filter repo.AuditFilter) ([]*model.AuditEvent, error) {
+	var events []*model.AuditEvent
+	err := r.store.read(func(d *data) error {
+		for i := len(d.auditEvents) - 1; i >= 0; i-- {
+			event := d.auditEvents[i]
+			switch {
+			case filter.SwiftCode != "" && event.SwiftCode.String() != filter.SwiftCode,
+				filter.Actor != "" && event.Actor != filter.Actor,
+				!filter.From.IsZero() && event.OccurredAt.Before(filter.From),
+				!filter.To.IsZero() && !event.OccurredAt.Before(filter.To),
+				filter.BeforeID != 0 && event.ID >= filter.BeforeID:
+				continue
+			}
+			event.Before = snapshotOf(event.Before)
+			event.After = snapshotOf(event.After)
+			events = append(events, &event)
+		}
+		return nil
+	})
+	return limit(events, filter.Limit), err
+}
+
+// auditChange is a change to be recorded by recordAuditEvents.
+type auditChange struct {
+	action model.AuditAction
+	before *model.BankUnit
+	after  *model.BankUnit
+}
+
+func (c auditChange) swiftCode() model.SwiftCode {
+	if c.after != nil {
+		return c.after.SwiftCode.Canonical()
+	}
+	return c.before.SwiftCode.Canonical()
+}
+
+// recordAuditEvents stores one audit event per change, taking the actor and request
+// id from ctx. It is called within the write making the changes, so the events are
+// kept only together with them.
+func (d *data) recordAuditEvents(ctx context.Context, changes []auditChange, at time.Time) {
+	actor, requestID := audit.Actor(ctx), audit.RequestID(ctx)
+	for _, c := range changes {
+		d.auditEvents = append(d.auditEvents, model.AuditEvent{
+			ID:         d.nextID(),
+			OccurredAt: at,
+			Actor:      actor,
+			Action:     c.action,
+			SwiftCode:  c.swiftCode(),
+			RequestID:  requestID,
+			Before:     snapshotOf(c.before),
+			After:      snapshotOf(c.after),
+		})
+	}
+}
+
+// snapshotOf copies the fields of the bank unit an audit event keeps, as stored
+// in the before and after columns of audit_events.
+func snapshotOf(bankUnit *model.BankUnit) *model.BankUnit {
+	if bankUnit == nil {
+		return nil
+	}
+	return &model.BankUnit{
+		SwiftCode:     bankUnit.SwiftCode.Canonical(),
+		Country:       bankUnit.Country,
+		Address:       bankUnit.Address,
+		Name:          bankUnit.Name,
+		IsHeadquarter: bankUnit.IsHeadquarter,
+		CodeType:      bankUnit.CodeType,
+		TownName:      bankUnit.TownName,
+		TimeZone:      bankUnit.TimeZone,
+	}
+}
+
+func createdChanges(bankUnits []*model.BankUnit) []auditChange {
+	changes := make([]auditChange, len(bankUnits))
+	for i, bankUnit := range bankUnits {
+		changes[i] = auditChange{action: model.AuditCreate, after: bankUnit}
+	}
+	return changes
+}
+
+func deletedChanges(bankUnits []*model.BankUnit) []auditChange {
+	changes := make([]auditChange, len(bankUnits))
+	for i, bankUnit := range bankUnits {
+		changes[i] = auditChange{action: model.AuditDelete, before: bankUnit}
+	}
+	return changes
+}
@@ -0,0 +1,500 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+type BankUnitRepo struct {
+	store *Store
+}
+
+func NewBankUnitRepo(store *Store) *BankUnitRepo {
+	return &BankUnitRepo{store: store}
+}
+
+func (r *BankUnitRepo) Create(ctx context.Context, bankUnit *model.BankUnit) error {
+	return r.store.write(func(d *data) error {
+		at := now()
+		if err := d.insertBankUnits([]*model.BankUnit{bankUnit}, at); err != nil {
+			return err
+		}
+		d.recordAuditEvents(ctx, createdChanges([]*model.BankUnit{bankUnit}), at)
+		return nil
+	})
+}
+
+func (r *BankUnitRepo) BulkCreate(ctx context.Context, bankUnits []*model.BankUnit) error {
+	return r.store.write(func(d *data) error {
+		at := now()
+		if err := d.insertBankUnits(bankUnits, at); err != nil {
+			return err
+		}
+		d.recordAuditEvents(ctx, createdChanges(bankUnits), at)
+		return nil
+	})
+}
+
+func (r *BankUnitRepo) BulkCreateFrom(_ context.Context, bankUnits iter.Seq2[*model.BankUnit, error]) (int, error) {
+	var created int
+	err := r.store.write(func(d *data) error {
+		at := now()
+		for bankUnit, err := range bankUnits {
+			if err != nil {
+				return err
+			}
+			if err := d.insertBankUnits([]*model.BankUnit{bankUnit}, at); err != nil {
+				return err
+			}
+			created++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+func (r *BankUnitRepo) BulkUpsert(_ context.Context, bankUnits iter.Seq2[*model.BankUnit, error]) (int, error) {
+	var changed int
+	err := r.store.write(func(d *data) error {
+		at := now()
+		seen := map[string]bool{}
+		for bankUnit, err := range bankUnits {
+			if err != nil {
+				return err
+			}
+			// like DISTINCT ON, only the first bank unit with a swift code counts
+			code := bankUnit.SwiftCode.String()
+			if seen[code] {
+				continue
+			}
+			seen[code] = true
+
+			// changed bank units get their current version closed and a new one
+			// inserted, new bank units are inserted and unchanged ones are left alone
+			if i, ok := d.live[code]; ok {
+				if sameFields(&d.bankUnits[i].unit, bankUnit) {
+					continue
+				}
+				d.closeVersion(i, at)
+			}
+			if err := d.insertBankUnits([]*model.BankUnit{bankUnit}, at); err != nil {
+				return err
+			}
+			changed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+func (r *BankUnitRepo) Update(ctx context.Context, bankUnit *model.BankUnit) error {
+	return r.store.write(func(d *data) error {
+		at := now()
+		before, err := d.updateBankUnit(bankUnit, at)
+		if err != nil {
+			return err
+		}
+		d.recordAuditEvents(ctx, []auditChange{{action: model.AuditUpdate, before: before, after: bankUnit}}, at)
+		return nil
+	})
+}
+
+func (r *BankUnitRepo) Delete(ctx context.Context, swiftCode model.SwiftCode, opts repo.DeleteOptions) error {
+	return r.store.write(func(d *data) error {
+		i, ok := d.live[swiftCode.String()]
+		if !ok {
+			return repo.ErrNotFound
+		}
+		units := []*model.BankUnit{d.view(i)}
+		deleted := []int{i}
+
+		if units[0].IsHeadquarter {
+			branches := d.liveBranches(swiftCode)
+			if len(branches) > 0 && !opts.Cascade {
+				return repo.ErrHasBranches
+			}
+			for _, branch := range branches {
+				units = append(units, d.view(branch))
+			}
+			deleted = append(deleted, branches...)
+		}
+
+		at := now()
+		for _, i := range deleted {
+			d.markDeleted(i, at)
+		}
+		d.recordAuditEvents(ctx, deletedChanges(units), at)
+		return nil
+	})
+}
+
+func (r *BankUnitRepo) GetBySwiftCode(_ context.Context, swiftCode model.SwiftCode, opts repo.ReadOptions) (*model.BankUnit, error) {
+	var bankUnit *model.BankUnit
+	err := r.store.read(func(d *data) error {
+		for _, unit := range d.bankUnitsAt(opts.AsOf) {
+			if unit.SwiftCode.String() != swiftCode.String() || (!opts.IncludeDeleted && unit.DeletedAt != nil) {
+				continue
+			}
+			// the live bank unit comes first, then the most recently deleted one
+			switch {
+			case bankUnit == nil, unit.DeletedAt == nil:
+				bankUnit = unit
+			case bankUnit.DeletedAt != nil && unit.DeletedAt.After(*bankUnit.DeletedAt):
+				bankUnit = unit
+			}
+		}
+		if bankUnit == nil {
+			return repo.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bankUnit, nil
+}
+
+func (r *BankUnitRepo) Restore(ctx context.Context, swiftCode model.SwiftCode) (*model.BankUnit, error) {
+	var restored *model.BankUnit
+	err := r.store.write(func(d *data) error {
+		deleted := -1
+		for i, row := range d.bankUnits {
+			if row.unit.SwiftCode.String() != swiftCode.String() || row.unit.ValidTo != nil || row.unit.DeletedAt == nil {
+				continue
+			}
+			if deleted < 0 || !row.unit.DeletedAt.Before(*d.bankUnits[deleted].unit.DeletedAt) {
+				deleted = i
+			}
+		}
+		if deleted < 0 {
+			return repo.ErrNotFound
+		}
+
+		// the deleted version is kept as history and a live copy of it becomes the current version
+		at := now()
+		unit := d.bankUnits[deleted].unit
+		d.closeVersion(deleted, at)
+		if err := d.insertBankUnits([]*model.BankUnit{&unit}, at); err != nil {
+			return err
+		}
+		restored = d.view(len(d.bankUnits) - 1)
+		d.recordAuditEvents(ctx, []auditChange{{action: model.AuditRestore, after: restored}}, at)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+func (r *BankUnitRepo) GetDeleted(_ context.Context, opts repo.DeletedListOptions) ([]*model.BankUnit, error) {
+	var deleted []*model.BankUnit
+	err := r.store.read(func(d *data) error {
+		for i := len(d.bankUnits) - 1; i >= 0; i-- {
+			unit := d.bankUnits[i].unit
+			if unit.ValidTo == nil && unit.DeletedAt != nil && !unit.DeletedAt.Before(opts.Since) {
+				deleted = append(deleted, d.view(i))
+			}
+		}
+		return nil
+	})
+	// rows are visited from the newest, so a stable sort keeps ties in descending id order
+	slices.SortStableFunc(deleted, func(a, b *model.BankUnit) int {
+		return b.DeletedAt.Compare(*a.DeletedAt)
+	})
+	return limit(deleted, opts.Limit), err
+}
+
+func (r *BankUnitRepo) GetBySwiftCodes(_ context.Context, swiftCodes []model.SwiftCode) ([]*model.BankUnit, error) {
+	var bankUnits []*model.BankUnit
+	err := r.store.read(func(d *data) error {
+		seen := map[string]bool{}
+		for _, swiftCode := range swiftCodes {
+			code := swiftCode.String()
+			if i, ok := d.live[code]; ok && !seen[code] {
+				seen[code] = true
+				bankUnits = append(bankUnits, d.view(i))
+			}
+		}
+		return nil
+	})
+	return bankUnits, err
+}
+
+func (r *BankUnitRepo) GetAllByCountry(_ context.Context, countryISO2 model.CountryISO2, opts repo.ListOptions) ([]*model.BankUnit, error) {
+	return r.list(opts, func(unit *model.BankUnit) bool {
+		return unit.Country.Code == countryISO2
+	})
+}
+
+func (r *BankUnitRepo) FindByPattern(_ context.Context, pattern model.SwiftCodePattern, opts repo.ListOptions) ([]*model.BankUnit, error) {
+	return r.list(opts, func(unit *model.BankUnit) bool {
+		return pattern.Matches(unit.SwiftCode)
+	})
+}
+
+// list returns the bank units matching the filter and opts ordered by swift code.
+func (r *BankUnitRepo) list(opts repo.ListOptions, filter func(unit *model.BankUnit) bool) ([]*model.BankUnit, error) {
+	var bankUnits []*model.BankUnit
+	err := r.store.read(func(d *data) error {
+		for _, unit := range d.bankUnitsAt(opts.AsOf) {
+			if unit.DeletedAt != nil || !filter(unit) ||
+				opts.ExcludeTestBICs && unit.SwiftCode.IsTestBIC() ||
+				unit.SwiftCode.String() <= opts.After {
+				continue
+			}
+			bankUnits = append(bankUnits, unit)
+		}
+		return nil
+	})
+	sortBySwiftCode(bankUnits)
+	return limit(bankUnits, opts.Limit), err
+}
+
+func (r *BankUnitRepo) GetAll(_ context.Context, opts repo.ReadOptions) ([]*model.BankUnit, error) {
+	var bankUnits []*model.BankUnit
+	err := r.store.read(func(d *data) error {
+		for _, unit := range d.bankUnitsAt(time.Time{}) {
+			if opts.IncludeDeleted || unit.DeletedAt == nil {
+				bankUnits = append(bankUnits, unit)
+			}
+		}
+		return nil
+	})
+	return bankUnits, err
+}
+
+func (r *BankUnitRepo) GetBranches(_ context.Context, swiftCode model.SwiftCode, opts repo.ReadOptions) ([]*model.BankUnit, error) {
+	var branches []*model.BankUnit
+	err := r.store.read(func(d *data) error {
+		for _, unit := range d.bankUnitsAt(opts.AsOf) {
+			if isBranchOf(unit.SwiftCode, swiftCode) && (opts.IncludeDeleted || unit.DeletedAt == nil) {
+				branches = append(branches, unit)
+			}
+		}
+		return nil
+	})
+	sortBySwiftCode(branches)
+	return branches, err
+}
+
+func (r *BankUnitRepo) GetBranchesOf(_ context.Context, headquarters []model.SwiftCode) ([]*model.BankUnit, error) {
+	baseCodes := map[string]bool{}
+	hqCodes := map[string]bool{}
+	for _, hq := range headquarters {
+		baseCodes[hq.BaseCode()] = true
+		hqCodes[hq.String()] = true
+	}
+
+	var branches []*model.BankUnit
+	err := r.store.read(func(d *data) error {
+		for _, i := range d.live {
+			swiftCode := d.bankUnits[i].unit.SwiftCode
+			if baseCodes[swiftCode.BaseCode()] && !hqCodes[swiftCode.String()] {
+				branches = append(branches, d.view(i))
+			}
+		}
+		return nil
+	})
+	sortBySwiftCode(branches)
+	return branches, err
+}
+
+func (r *BankUnitRepo) DeleteAll(_ context.Context) error {
+	return r.store.write(func(d *data) error {
+		d.bankUnits = nil
+		clear(d.live)
+		return nil
+	})
+}
+
+func (r *BankUnitRepo) ApplyChangeset(ctx context.Context, changes repo.Changeset) error {
+	return r.store.write(func(d *data) error {
+		at := now()
+		var audited []auditChange
+
+		var deleted []*model.BankUnit
+		for _, swiftCode := range changes.Delete {
+			if i, ok := d.live[swiftCode.String()]; ok {
+				deleted = append(deleted, d.view(i))
+				d.markDeleted(i, at)
+			}
+		}
+		sortBySwiftCode(deleted)
+		audited = append(audited, deletedChanges(deleted)...)
+
+		for _, bankUnit := range changes.Update {
+			before, err := d.updateBankUnit(bankUnit, at)
+			if err != nil {
+				return fmt.Errorf("failed to update bank unit %s: %w", bankUnit.SwiftCode, err)
+			}
+			audited = append(audited, auditChange{action: model.AuditUpdate, before: before, after: bankUnit})
+		}
+
+		if err := d.insertBankUnits(changes.Create, at); err != nil {
+			return err
+		}
+		audited = append(audited, createdChanges(changes.Create)...)
+
+		d.recordAuditEvents(ctx, audited, at)
+		return nil
+	})
+}
+
+// insertBankUnits stores live versions of the bank units valid from at, enforcing the
+// constraints of the bank_units table: the country must exist and there can be only
+// one live bank unit with a swift code.
+func (d *data) insertBankUnits(bankUnits []*model.BankUnit, at time.Time) error {
+	for _, bankUnit := range bankUnits {
+		if _, ok := d.countries[bankUnit.Country.Code]; !ok {
+			return repo.ErrUnknownCountry
+		}
+		code := bankUnit.SwiftCode.String()
+		if _, ok := d.live[code]; ok {
+			return repo.ErrDuplicate
+		}
+
+		unit := *bankUnit
+		unit.SwiftCode = unit.SwiftCode.Canonical()
+		unit.Country.Name = ""
+		unit.DeletedAt = nil
+		unit.ValidFrom = at
+		unit.ValidTo = nil
+
+		d.setLive(code, len(d.bankUnits))
+		d.bankUnits = append(d.bankUnits, bankUnitRow{id: d.nextID(), unit: unit})
+	}
+	return nil
+}
+
+// updateBankUnit closes the current version of the live bank unit with the swift code
+// and inserts a new version with the given fields, it returns the previous version.
+func (d *data) updateBankUnit(bankUnit *model.BankUnit, at time.Time) (*model.BankUnit, error) {
+	i, ok := d.live[bankUnit.SwiftCode.String()]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	before := d.view(i)
+	d.closeVersion(i, at)
+	if err := d.insertBankUnits([]*model.BankUnit{bankUnit}, at); err != nil {
+		return nil, err
+	}
+	return before, nil
+}
+
+func (d *data) closeVersion(i int, at time.Time) {
+	d.changeBankUnit(i).ValidTo = &at
+	d.dropLive(i)
+}
+
+func (d *data) markDeleted(i int, at time.Time) {
+	d.changeBankUnit(i).DeletedAt = &at
+	d.dropLive(i)
+}
+
+// dropLive removes the row from the live index, closing a deleted version must
+// not drop the live bank unit created with the same swift code later.
+func (d *data) dropLive(i int) {
+	code := d.bankUnits[i].unit.SwiftCode.String()
+	if j, ok := d.live[code]; ok && j == i {
+		d.deleteLive(code)
+	}
+}
+
+// liveBranches returns the rows of the live branches of the headquarters ordered by swift code.
+func (d *data) liveBranches(headquarters model.SwiftCode) []int {
+	var branches []int
+	for _, i := range d.live {
+		if isBranchOf(d.bankUnits[i].unit.SwiftCode, headquarters) {
+			branches = append(branches, i)
+		}
+	}
+	slices.SortFunc(branches, func(a, b int) int {
+		return strings.Compare(d.bankUnits[a].unit.SwiftCode.String(), d.bankUnits[b].unit.SwiftCode.String())
+	})
+	return branches
+}
+
+// view returns a copy of the row with the country name filled in, like the
+// bank_units_with_country view.
+func (d *data) view(i int) *model.BankUnit {
+	unit := d.bankUnits[i].unit
+	unit.Country.Name = d.countries[unit.Country.Code]
+	unit.DeletedAt = copyTime(unit.DeletedAt)
+	unit.ValidTo = copyTime(unit.ValidTo)
+	return &unit
+}
+
+// bankUnitsAt returns the current versions of the bank units for the zero time,
+// deleted ones included, and the versions in effect at asOf otherwise, like the
+// bank_units_as_of function. Bank units are returned in the order they were stored.
+func (d *data) bankUnitsAt(asOf time.Time) []*model.BankUnit {
+	var bankUnits []*model.BankUnit
+	for i, row := range d.bankUnits {
+		unit := row.unit
+		switch {
+		case asOf.IsZero():
+			if unit.ValidTo != nil {
+				continue
+			}
+		case unit.ValidFrom.After(asOf),
+			unit.ValidTo != nil && !unit.ValidTo.After(asOf),
+			unit.DeletedAt != nil && !unit.DeletedAt.After(asOf):
+			continue
+		}
+
+		bankUnit := d.view(i)
+		if !asOf.IsZero() {
+			// as of the time the bank unit was not deleted yet
+			bankUnit.DeletedAt = nil
+		}
+		bankUnits = append(bankUnits, bankUnit)
+	}
+	return bankUnits
+}
+
+// sameFields reports whether storing b over a would change nothing.
+func sameFields(a, b *model.BankUnit) bool {
+	return a.Country.Code == b.Country.Code && a.Name == b.Name && a.Address == b.Address &&
+		a.IsHeadquarter == b.IsHeadquarter && a.CodeType == b.CodeType &&
+		a.TownName == b.TownName && a.TimeZone == b.TimeZone
+}
+
+func isBranchOf(swiftCode, headquarters model.SwiftCode) bool {
+	return swiftCode.BaseCode() == headquarters.BaseCode() && swiftCode.String() != headquarters.String()
+}
+
+func sortBySwiftCode(bankUnits []*model.BankUnit) {
+	slices.SortFunc(bankUnits, func(a, b *model.BankUnit) int {
+		return strings.Compare(a.SwiftCode.String(), b.SwiftCode.String())
+	})
+}
+
+// limit caps the number of items, 0 means no limit.
+func limit[T any](items []T, n int) []T {
+	if n > 0 && len(items) > n {
+		return items[:n]
+	}
+	return items
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
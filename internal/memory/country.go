@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+type CountryRepo struct {
+	store *Store
+}
+
+func NewCountryRepo(store *Store) *CountryRepo {
+	return &CountryRepo{store: store}
+}
+
+func (r *CountryRepo) BulkCreate(_ context.Context, countries []model.Country) error {
+	return r.store.write(func(d *data) error {
+		for _, country := range countries {
+			if _, ok := d.countries[country.Code]; ok {
+				return repo.ErrDuplicate
+			}
+			d.setCountry(country.Code, country.Name)
+		}
+		return nil
+	})
+}
+
+func (r *CountryRepo) BulkUpsert(_ context.Context, countries []model.Country) (int, error) {
+	var changed int
+	err := r.store.write(func(d *data) error {
+		seen := map[model.CountryISO2]bool{}
+		for _, country := range countries {
+			// like DISTINCT ON, only the first country with a code counts
+			if seen[country.Code] {
+				continue
+			}
+			seen[country.Code] = true
+
+			if name, ok := d.countries[country.Code]; ok && name == country.Name {
+				continue
+			}
+			d.setCountry(country.Code, country.Name)
+			changed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+func (r *CountryRepo) GetByCode(_ context.Context, code model.CountryISO2) (model.Country, error) {
+	var country model.Country
+	err := r.store.read(func(d *data) error {
+		name, ok := d.countries[code]
+		if !ok {
+			return repo.ErrNotFound
+		}
+		country = model.Country{Code: code, Name: name}
+		return nil
+	})
+	return country, err
+}
+
+func (r *CountryRepo) Exists(_ context.Context, country model.Country) (bool, error) {
+	var exists bool
+	err := r.store.read(func(d *data) error {
+		name, ok := d.countries[country.Code]
+		exists = ok && name == country.Name
+		return nil
+	})
+	return exists, err
+}
+
+func (r *CountryRepo) GetAll(_ context.Context) ([]model.Country, error) {
+	var countries []model.Country
+	err := r.store.read(func(d *data) error {
+		countries = make([]model.Country, 0, len(d.countries))
+		for code, name := range d.countries {
+			countries = append(countries, model.Country{Code: code, Name: name})
+		}
+		return nil
+	})
+	slices.SortFunc(countries, func(a, b model.Country) int {
+		return strings.Compare(a.Code.String(), b.Code.String())
+	})
+	return countries, err
+}
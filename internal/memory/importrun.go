@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+type ImportRunRepo struct {
+	store *Store
+}
+
+func NewImportRunRepo(store *Store) *ImportRunRepo {
+	return &ImportRunRepo{store: store}
+}
+
+func (r *ImportRunRepo) Create(_ context.Context, run model.ImportRun) error {
+	return r.store.write(func(d *data) error {
+		run.ImportedAt = now()
+		d.importRuns = append(d.importRuns, run)
+		return nil
+	})
+}
+
+func (r *ImportRunRepo) GetLatest(_ context.Context, source string) (model.ImportRun, error) {
+	var latest model.ImportRun
+	err := r.store.read(func(d *data) error {
+		// runs are stored in the order they were imported
+		for i := len(d.importRuns) - 1; i >= 0; i-- {
+			if d.importRuns[i].Source == source {
+				latest = d.importRuns[i]
+				return nil
+			}
+		}
+		return repo.ErrNotFound
+	})
+	return latest, err
+}
@@ -0,0 +1,21 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/pkarmon/swiftcodes/internal/memory"
+	"github.com/pkarmon/swiftcodes/internal/repo/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		store := memory.NewStore()
+		return repotest.Repos{
+			BankUnits:  memory.NewBankUnitRepo(store),
+			Countries:  memory.NewCountryRepo(store),
+			Audit:      memory.NewAuditRepo(store),
+			APIKeys:    memory.NewAPIKeyRepo(store),
+			ImportRuns: memory.NewImportRunRepo(store),
+		}
+	})
+}
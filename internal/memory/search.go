@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+// searchThreshold is the lowest score a bank unit needs to be found, it matches
+// the default threshold of word similarity in pg_trgm.
+const searchThreshold = 0.6
+
+// Search approximates the postgres search with trigrams: every word of the query is
+// scored by its most similar word of the name, town or address, and the score of a
+// bank unit is the average over the query words. Accents are ignored on both sides.
+func (r *BankUnitRepo) Search(_ context.Context, query repo.SearchQuery) ([]repo.SearchResult, error) {
+	terms := searchWords(query.Text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var results []repo.SearchResult
+	err := r.store.read(func(d *data) error {
+		for _, i := range d.live {
+			unit := d.bankUnits[i].unit
+			if query.CountryISO2.String() != "" && unit.Country.Code != query.CountryISO2 ||
+				query.HeadquartersOnly && !unit.IsHeadquarter {
+				continue
+			}
+
+			score := searchScore(terms, searchWords(unit.Name+" "+unit.TownName+" "+unit.Address))
+			if score >= searchThreshold {
+				results = append(results, repo.SearchResult{BankUnit: d.view(i), Score: score})
+			}
+		}
+		return nil
+	})
+
+	slices.SortFunc(results, func(a, b repo.SearchResult) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.BankUnit.SwiftCode.String(), b.BankUnit.SwiftCode.String())
+	})
+	return limit(results, query.Limit), err
+}
+
+func searchScore(terms, words []string) float64 {
+	var total float64
+	for _, term := range terms {
+		var best float64
+		for _, word := range words {
+			best = max(best, similarity(term, word))
+		}
+		total += best
+	}
+	return total / float64(len(terms))
+}
+
+// similarity is the share of the trigrams of term found in word.
+func similarity(term, word string) float64 {
+	termTrigrams := trigrams(term)
+	wordTrigrams := map[string]bool{}
+	for _, t := range trigrams(word) {
+		wordTrigrams[t] = true
+	}
+
+	var shared int
+	for _, t := range termTrigrams {
+		if wordTrigrams[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(termTrigrams))
+}
+
+// trigrams returns the distinct trigrams of the word padded like in pg_trgm,
+// with two spaces in front and one at the end.
+func trigrams(word string) []string {
+	runes := []rune("  " + word + " ")
+	var result []string
+	for i := 0; i+3 <= len(runes); i++ {
+		t := string(runes[i : i+3])
+		if !slices.Contains(result, t) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// searchWords splits the text into lower case words without accents.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(unaccent.Replace(strings.ToLower(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// unaccent replaces the accented lower case letters of Latin alphabets with their
+// base letters, like the unaccent extension does.
+var unaccent = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "ą", "a", "ă", "a", "ā", "a",
+	"ç", "c", "ć", "c", "č", "c",
+	"ď", "d", "đ", "d",
+	"è", "e", "é", "e", "ê", "e", "ë", "e", "ę", "e", "ě", "e", "ē", "e",
+	"ğ", "g",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ī", "i", "ı", "i",
+	"ł", "l", "ľ", "l", "ĺ", "l",
+	"ñ", "n", "ń", "n", "ň", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "ő", "o", "ō", "o",
+	"ŕ", "r", "ř", "r",
+	"ś", "s", "š", "s", "ş", "s", "ș", "s", "ß", "ss",
+	"ť", "t", "ţ", "t", "ț", "t",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ů", "u", "ű", "u", "ū", "u",
+	"ý", "y", "ÿ", "y",
+	"ź", "z", "ż", "z", "ž", "z",
+	"æ", "ae", "œ", "oe",
+)
@@ -0,0 +1,165 @@
+// Package memory implements the repositories without a database, for tests and for
+// deployments that cannot reach one. Everything is lost when the process exits.
+//
+// The repositories follow the semantics of the postgres ones, including bank unit
+// versions, soft deletes and audit events, so they can replace each other.
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+)
+
+// Store holds the data shared by the repositories created from it. Writes change the
+// data in place and record how to undo every change, a failed write is rolled back, so
+// writes are atomic like the transactions of the postgres repositories while costing
+// only what they touch.
+type Store struct {
+	mu   sync.RWMutex
+	data *data
+}
+
+func NewStore() *Store {
+	return &Store{data: newData()}
+}
+
+// Clear removes all data, as if the store was just created.
+func (s *Store) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = newData()
+}
+
+type data struct {
+	countries map[model.CountryISO2]string
+	// bankUnits holds every version of every bank unit in the order they were
+	// stored, like the bank_units table.
+	bankUnits []bankUnitRow
+	// live indexes the rows of live bank units by swift code.
+	live        map[string]int
+	auditEvents []model.AuditEvent
+	apiKeys     []apiKeyRow
+	importRuns  []model.ImportRun
+	lastID      int64
+	// undo reverts the in place changes of the running write, latest first.
+	undo []func()
+}
+
+type bankUnitRow struct {
+	id   int64
+	unit model.BankUnit
+}
+
+type apiKeyRow struct {
+	key        model.APIKey
+	secretHash string
+}
+
+func newData() *data {
+	return &data{countries: map[model.CountryISO2]string{}, live: map[string]int{}}
+}
+
+// savepoint is the state before a write. The slices are only appended to, apart from
+// the changes recorded in undo, so truncating them drops what the write appended.
+type savepoint struct {
+	bankUnits, auditEvents, apiKeys, importRuns int
+	lastID                                      int64
+}
+
+func (d *data) savepoint() savepoint {
+	return savepoint{
+		bankUnits:   len(d.bankUnits),
+		auditEvents: len(d.auditEvents),
+		apiKeys:     len(d.apiKeys),
+		importRuns:  len(d.importRuns),
+		lastID:      d.lastID,
+	}
+}
+
+func (d *data) rollback(sp savepoint) {
+	for i := len(d.undo) - 1; i >= 0; i-- {
+		d.undo[i]()
+	}
+	d.bankUnits = d.bankUnits[:sp.bankUnits]
+	d.auditEvents = d.auditEvents[:sp.auditEvents]
+	d.apiKeys = d.apiKeys[:sp.apiKeys]
+	d.importRuns = d.importRuns[:sp.importRuns]
+	d.lastID = sp.lastID
+}
+
+// changeBankUnit returns the stored bank unit of row i to be changed in place.
+func (d *data) changeBankUnit(i int) *model.BankUnit {
+	saved := d.bankUnits[i]
+	d.undo = append(d.undo, func() { d.bankUnits[i] = saved })
+	return &d.bankUnits[i].unit
+}
+
+// changeAPIKey returns the stored key of row i to be changed in place.
+func (d *data) changeAPIKey(i int) *model.APIKey {
+	saved := d.apiKeys[i]
+	d.undo = append(d.undo, func() { d.apiKeys[i] = saved })
+	return &d.apiKeys[i].key
+}
+
+func (d *data) setLive(code string, i int) {
+	d.undo = append(d.undo, restoreFunc(d.live, code))
+	d.live[code] = i
+}
+
+func (d *data) deleteLive(code string) {
+	d.undo = append(d.undo, restoreFunc(d.live, code))
+	delete(d.live, code)
+}
+
+func (d *data) setCountry(code model.CountryISO2, name string) {
+	d.undo = append(d.undo, restoreFunc(d.countries, code))
+	d.countries[code] = name
+}
+
+// restoreFunc returns a function setting the key of m back to its current state.
+func restoreFunc[K comparable, V any](m map[K]V, key K) func() {
+	v, ok := m[key]
+	return func() {
+		if ok {
+			m[key] = v
+		} else {
+			delete(m, key)
+		}
+	}
+}
+
+func (d *data) nextID() int64 {
+	d.lastID++
+	return d.lastID
+}
+
+func (s *Store) read(fn func(d *data) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return fn(s.data)
+}
+
+// write runs fn on the data and rolls its changes back when fn fails. fn must make
+// in place changes through the helpers recording them, like setLive, and may append.
+func (s *Store) write(fn func(d *data) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sp := s.data.savepoint()
+	err := fn(s.data)
+	if err != nil {
+		s.data.rollback(sp)
+	}
+	s.data.undo = nil
+	return err
+}
+
+// now returns the time of a write. It is truncated to microseconds as in postgres,
+// so times read back can be compared with times written.
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/pkarmon/swiftcodes/internal/audit"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds request ids given by clients, they end up in every audit event.
+const maxRequestIDLen = 128
+
+// Audit stores the request id in the request context, where the repositories pick it
+// up when recording audit events, together with the anonymous actor until Authenticate
+// identifies the caller. A request id given by the client is kept when it is at most
+// 128 printable ASCII characters without spaces, otherwise a new one is generated; it
+// is echoed in the response.
+func Audit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := audit.WithRequestID(r.Context(), requestID)
+		ctx = audit.WithActor(ctx, audit.ActorAnonymous)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
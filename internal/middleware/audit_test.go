@@ -0,0 +1,46 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkarmon/swiftcodes/internal/audit"
+	"github.com/pkarmon/swiftcodes/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditRequestID(t *testing.T) {
+	var requestID string
+	h := middleware.Audit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = audit.RequestID(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(header string) string {
+		req := httptest.NewRequest("GET", "/", nil)
+		if header != "" {
+			req.Header.Set(middleware.RequestIDHeader, header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, requestID, rec.Header().Get(middleware.RequestIDHeader))
+		return requestID
+	}
+
+	assert.Equal(t, "batch-42/7f3a", serve("batch-42/7f3a"))
+	assert.Equal(t, strings.Repeat("a", 128), serve(strings.Repeat("a", 128)))
+
+	for name, header := range map[string]string{
+		"missing":           "",
+		"too long":          strings.Repeat("a", 129),
+		"control character": "abc\x1bdef",
+		"space":             "abc def",
+		"non ascii":         "zażółć",
+	} {
+		id := serve(header)
+		assert.Len(t, id, 32, name)
+		assert.NotEqual(t, header, id, name)
+	}
+}
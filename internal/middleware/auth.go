@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/pkarmon/swiftcodes/internal/audit"
+	"github.com/pkarmon/swiftcodes/internal/auth"
+	"github.com/pkarmon/swiftcodes/internal/handlers"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// Authenticate resolves the caller from the X-API-Key header or an Authorization bearer
+// token and stores the principal in the request context, it also becomes the actor of
+// audit events. Requests without credentials get the anonymous principal, requests
+// with invalid ones are rejected.
+func Authenticate(authn *auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := authenticate(r, authn)
+			if errors.Is(err, auth.ErrInvalidCredentials) {
+				sendUnauthorized(w, "invalid credentials")
+				return
+			}
+			if err != nil {
+				handlers.SendServerError(w)
+				return
+			}
+
+			ctx := auth.WithPrincipal(r.Context(), principal)
+			if !principal.Anonymous {
+				ctx = audit.WithActor(ctx, principal.Subject)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func authenticate(r *http.Request, authn *auth.Authenticator) (auth.Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return authn.AuthenticateAPIKey(r.Context(), key)
+	}
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return auth.Principal{}, auth.ErrInvalidCredentials
+		}
+		return authn.AuthenticateToken(strings.TrimSpace(token))
+	}
+	return authn.Anonymous(), nil
+}
+
+// Require only lets through callers having at least the given role. Anonymous callers
+// get 401 so they know to authenticate, authenticated ones 403.
+func Require(role auth.Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFrom(r.Context())
+		switch {
+		case ok && principal.Role.Includes(role):
+			next(w, r)
+		case !ok || principal.Anonymous:
+			sendUnauthorized(w, "authentication required")
+		default:
+			handlers.SendErrorMsg(w, http.StatusForbidden, "the "+role.String()+" role is required")
+		}
+	}
+}
+
+func sendUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="swiftcodes"`)
+	handlers.SendErrorMsg(w, http.StatusUnauthorized, message)
+}
@@ -0,0 +1,87 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkarmon/swiftcodes/internal/audit"
+	"github.com/pkarmon/swiftcodes/internal/auth"
+	"github.com/pkarmon/swiftcodes/internal/handlers"
+	"github.com/pkarmon/swiftcodes/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticate(t *testing.T) {
+	keys, err := auth.ParseStaticKeys("viewer:reader:reader-key,ci:editor:editor-key")
+	assert.NoError(t, err)
+
+	var actor string
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		actor = audit.Actor(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /read", middleware.Require(auth.RoleReader, ok))
+	mux.HandleFunc("POST /write", middleware.Require(auth.RoleEditor, ok))
+
+	serve := func(anonymousRole auth.Role, method, target string, headers map[string]string) *httptest.ResponseRecorder {
+		h := middleware.Audit(middleware.Authenticate(auth.NewAuthenticator(keys, nil, anonymousRole))(mux))
+		req := httptest.NewRequest(method, target, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name          string
+		anonymousRole auth.Role
+		method        string
+		target        string
+		headers       map[string]string
+		status        int
+		message       string
+		actor         string
+	}{
+		{"anonymous read", auth.RoleReader, "GET", "/read", nil, http.StatusNoContent, "", audit.ActorAnonymous},
+		{"anonymous read not allowed", auth.RoleNone, "GET", "/read", nil,
+			http.StatusUnauthorized, "authentication required", ""},
+		{"anonymous write", auth.RoleReader, "POST", "/write", nil,
+			http.StatusUnauthorized, "authentication required", ""},
+		{"reader write", auth.RoleReader, "POST", "/write", map[string]string{middleware.APIKeyHeader: "reader-key"},
+			http.StatusForbidden, "the editor role is required", ""},
+		{"editor write", auth.RoleNone, "POST", "/write", map[string]string{middleware.APIKeyHeader: "editor-key"},
+			http.StatusNoContent, "", "ci"},
+		{"editor read", auth.RoleNone, "GET", "/read", map[string]string{middleware.APIKeyHeader: "editor-key"},
+			http.StatusNoContent, "", "ci"},
+		{"invalid key", auth.RoleReader, "GET", "/read", map[string]string{middleware.APIKeyHeader: "guess"},
+			http.StatusUnauthorized, "invalid credentials", ""},
+		{"bearer without jwks", auth.RoleReader, "GET", "/read", map[string]string{"Authorization": "Bearer a.b.c"},
+			http.StatusUnauthorized, "invalid credentials", ""},
+		{"basic auth", auth.RoleReader, "GET", "/read", map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+			http.StatusUnauthorized, "invalid credentials", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actor = ""
+			rec := serve(tt.anonymousRole, tt.method, tt.target, tt.headers)
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, tt.actor, actor)
+			if tt.message == "" {
+				return
+			}
+			errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.message, errMsg.Message)
+			if tt.status == http.StatusUnauthorized {
+				assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"log"
 	"net/http"
 	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/audit"
 )
 
 type responseWriter struct {
@@ -28,11 +30,12 @@ func Logging(next http.Handler) http.Handler {
 		next.ServeHTTP(wrapped, r)
 
 		log.Printf(
-			"%s %s %d %s",
+			"%s %s %d %s %s",
 			r.Method,
 			r.RequestURI,
 			wrapped.status,
 			time.Since(start),
+			audit.RequestID(r.Context()),
 		)
 	})
 }
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/auth"
+	"github.com/pkarmon/swiftcodes/internal/handlers"
+	"github.com/pkarmon/swiftcodes/internal/ratelimit"
+)
+
+// RateLimitOptions configures the RateLimit middleware. A nil limiter does not limit.
+type RateLimitOptions struct {
+	// Reads limits GET, HEAD and OPTIONS requests and the ReadOnlyPaths, Writes every
+	// other request.
+	Reads  *ratelimit.Limiter
+	Writes *ratelimit.Limiter
+	// ReadOnlyPaths are paths only reading data whatever their method, e.g. a POST
+	// carrying a query in its body.
+	ReadOnlyPaths []string
+	// TrustForwardedFor identifies anonymous clients by the last address in the
+	// X-Forwarded-For header, it must only be set behind a proxy that appends it.
+	TrustForwardedFor bool
+}
+
+// RateLimit takes a token per request from the bucket of the caller, callers with an
+// API key are told apart by the key, other authenticated callers by their subject and
+// anonymous ones by their address. It has to run after Authenticate. Requests over the
+// limit get 429 with a Retry-After header, every limited response carries the
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers.
+func RateLimit(opts RateLimitOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limiter := opts.Writes
+			if isRead(r, opts.ReadOnlyPaths) {
+				limiter = opts.Reads
+			}
+			if allow(w, limiter, clientKey(r, opts.TrustForwardedFor)) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// RateLimitByAddress takes a token per request from the bucket of the client address.
+// It runs before Authenticate, so callers presenting invalid credentials are limited
+// before their keys are looked up. See RateLimitOptions for trustForwardedFor.
+func RateLimitByAddress(limiter *ratelimit.Limiter, trustForwardedFor bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if allow(w, limiter, addressKey(r, trustForwardedFor)) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// allow takes a token for the client and sets the rate limit headers. It answers
+// the request and returns false when the bucket is empty.
+func allow(w http.ResponseWriter, limiter *ratelimit.Limiter, client string) bool {
+	if limiter == nil {
+		return true
+	}
+
+	d := limiter.Allow(client, time.Now())
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(seconds(d.Reset)))
+	if !d.Allowed {
+		h.Set("Retry-After", strconv.Itoa(seconds(d.RetryAfter)))
+		handlers.SendErrorMsg(w, http.StatusTooManyRequests, "rate limit exceeded")
+		return false
+	}
+	return true
+}
+
+func isRead(r *http.Request, readOnlyPaths []string) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return slices.Contains(readOnlyPaths, r.URL.Path)
+}
+
+func clientKey(r *http.Request, trustForwardedFor bool) string {
+	if p, ok := auth.PrincipalFrom(r.Context()); ok && !p.Anonymous {
+		if p.KeyID != "" {
+			return "key:" + p.KeyID
+		}
+		return "subject:" + p.Subject
+	}
+	return addressKey(r, trustForwardedFor)
+}
+
+func addressKey(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			addrs := strings.Split(forwarded, ",")
+			return "ip:" + strings.TrimSpace(addrs[len(addrs)-1])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// seconds rounds up, so clients retrying after the given time are not refused again.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,147 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkarmon/swiftcodes/internal/auth"
+	"github.com/pkarmon/swiftcodes/internal/handlers"
+	"github.com/pkarmon/swiftcodes/internal/middleware"
+	"github.com/pkarmon/swiftcodes/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingKeys counts the keys looked up in the wrapped store.
+type countingKeys struct {
+	auth.KeyStore
+	lookups int
+}
+
+func (k *countingKeys) Lookup(ctx context.Context, key string) (auth.Principal, error) {
+	k.lookups++
+	return k.KeyStore.Lookup(ctx, key)
+}
+
+func TestRateLimit(t *testing.T) {
+	staticKeys, err := auth.ParseStaticKeys("ci:editor:ci-key,ci:editor:ci-key-2")
+	assert.NoError(t, err)
+	keys := &countingKeys{KeyStore: staticKeys}
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	newHandler := func(trustForwardedFor bool) http.Handler {
+		limited := middleware.RateLimit(middleware.RateLimitOptions{
+			Reads:             ratelimit.NewLimiter(60, 2),
+			Writes:            ratelimit.NewLimiter(6, 1),
+			ReadOnlyPaths:     []string{"/lookup"},
+			TrustForwardedFor: trustForwardedFor,
+		})
+		return middleware.Authenticate(auth.NewAuthenticator(keys, nil, auth.RoleReader))(limited(http.HandlerFunc(ok)))
+	}
+
+	serveURL := func(h http.Handler, method, url, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	serve := func(h http.Handler, method, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+		return serveURL(h, method, "/", remoteAddr, headers)
+	}
+
+	t.Run("reads and writes are limited separately", func(t *testing.T) {
+		h := newHandler(false)
+
+		rec := serve(h, "GET", "10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "1", rec.Header().Get("RateLimit-Reset"))
+
+		assert.Equal(t, http.StatusNoContent, serve(h, "GET", "10.0.0.1:1234", nil).Code)
+
+		rec = serve(h, "GET", "10.0.0.1:5678", nil)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+		assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+		errMsg, err := handlers.Decode[handlers.ErrorResponse](rec.Result().Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "rate limit exceeded", errMsg.Message)
+
+		assert.Equal(t, http.StatusNoContent, serve(h, "POST", "10.0.0.1:1234", nil).Code)
+		rec = serve(h, "POST", "10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "10", rec.Header().Get("Retry-After"))
+	})
+
+	t.Run("read only paths are limited as reads", func(t *testing.T) {
+		h := newHandler(false)
+
+		for range 2 {
+			assert.Equal(t, http.StatusNoContent, serveURL(h, "POST", "/lookup", "10.0.0.1:1234", nil).Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, serveURL(h, "POST", "/lookup", "10.0.0.1:1234", nil).Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(h, "GET", "10.0.0.1:1234", nil).Code)
+		assert.Equal(t, http.StatusNoContent, serve(h, "POST", "10.0.0.1:1234", nil).Code)
+	})
+
+	t.Run("authenticated callers have their own bucket", func(t *testing.T) {
+		h := newHandler(false)
+		key := map[string]string{middleware.APIKeyHeader: "ci-key"}
+
+		assert.Equal(t, http.StatusNoContent, serve(h, "POST", "10.0.0.1:1234", nil).Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(h, "POST", "10.0.0.1:1234", nil).Code)
+		assert.Equal(t, http.StatusNoContent, serve(h, "POST", "10.0.0.1:1234", key).Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(h, "POST", "10.0.0.2:1234", key).Code)
+	})
+
+	t.Run("every key has its own bucket", func(t *testing.T) {
+		h := newHandler(false)
+		key := map[string]string{middleware.APIKeyHeader: "ci-key"}
+		otherKey := map[string]string{middleware.APIKeyHeader: "ci-key-2"}
+
+		assert.Equal(t, http.StatusNoContent, serve(h, "POST", "10.0.0.1:1234", key).Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(h, "POST", "10.0.0.1:1234", key).Code)
+		assert.Equal(t, http.StatusNoContent, serve(h, "POST", "10.0.0.1:1234", otherKey).Code)
+	})
+
+	t.Run("invalid keys are limited by address", func(t *testing.T) {
+		h := middleware.RateLimitByAddress(ratelimit.NewLimiter(6, 2), false)(newHandler(false))
+		guess := map[string]string{middleware.APIKeyHeader: "sc_guess"}
+		lookups := keys.lookups
+
+		assert.Equal(t, http.StatusUnauthorized, serve(h, "GET", "10.0.0.1:1234", guess).Code)
+		assert.Equal(t, http.StatusUnauthorized, serve(h, "GET", "10.0.0.1:1234", guess).Code)
+		for range 3 {
+			rec := serve(h, "GET", "10.0.0.1:1234", guess)
+			assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+			assert.Equal(t, "10", rec.Header().Get("Retry-After"))
+		}
+		assert.Equal(t, lookups+2, keys.lookups)
+
+		assert.Equal(t, http.StatusNoContent, serve(h, "GET", "10.0.0.2:1234", nil).Code)
+	})
+
+	t.Run("forwarded for", func(t *testing.T) {
+		forwarded := func(addrs string) map[string]string {
+			return map[string]string{"X-Forwarded-For": addrs}
+		}
+
+		h := newHandler(true)
+		assert.Equal(t, http.StatusNoContent, serve(h, "POST", "10.0.0.1:1234", forwarded("1.1.1.1, 2.2.2.2")).Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(h, "POST", "10.0.0.1:1234", forwarded("3.3.3.3, 2.2.2.2")).Code)
+		assert.Equal(t, http.StatusNoContent, serve(h, "POST", "10.0.0.1:1234", forwarded("2.2.2.2, 4.4.4.4")).Code)
+
+		h = newHandler(false)
+		assert.Equal(t, http.StatusNoContent, serve(h, "POST", "10.0.0.1:1234", forwarded("1.1.1.1")).Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(h, "POST", "10.0.0.1:1234", forwarded("2.2.2.2")).Code)
+	})
+}
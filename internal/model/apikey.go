@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// APIKey describes an issued API key. The key itself is only known to its owner,
+// it is stored hashed and told apart from other keys by its Prefix.
+type APIKey struct {
+	ID     int64
+	Prefix string
+	Owner  string
+	// Scopes are the roles the key grants, the highest of them applies.
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	// RotatedFrom is the id of the key this one replaced.
+	RotatedFrom *int64
+}
+
+// Active reports whether the key can still be used at the given time.
+func (k *APIKey) Active(at time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || at.Before(*k.ExpiresAt))
+}
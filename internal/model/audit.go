@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+)
+
+// AuditEvent records a single change of a bank unit. Before is nil for created
+// bank units and After is nil for deleted ones.
+type AuditEvent struct {
+	ID         int64
+	OccurredAt time.Time
+	Actor      string
+	Action     AuditAction
+	SwiftCode  SwiftCode
+	RequestID  string
+	Before     *BankUnit
+	After      *BankUnit
+}
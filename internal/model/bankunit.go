@@ -2,16 +2,27 @@ package model
 
 import (
 	"errors"
-	"fmt"
 	"strings"
+	"time"
 )
 
+var ErrInvalidTimeZone = errors.New("time zone must be a valid IANA time zone name")
+
 type BankUnit struct {
 	SwiftCode     SwiftCode
 	Country       Country
 	Address       string
 	Name          string
 	IsHeadquarter bool
+	CodeType      string
+	TownName      string
+	TimeZone      string
+	// DeletedAt is set for deleted bank units, which are kept for history.
+	DeletedAt *time.Time
+	// ValidFrom and ValidTo bound the time this version of the bank unit was in effect,
+	// ValidTo is nil for the current version.
+	ValidFrom time.Time
+	ValidTo   *time.Time
 }
 
 func NewBankUnit(
@@ -45,7 +56,7 @@ func NewBankUnit(
 	}
 
 	return &BankUnit{
-		SwiftCode:     swiftcode,
+		SwiftCode:     swiftcode.Canonical(),
 		Country:       country,
 		Address:       address,
 		Name:          name,
@@ -53,21 +64,114 @@ func NewBankUnit(
 	}, nil
 }
 
+// ValidateTimeZone checks that name is present in the tz database, e.g. "Europe/Warsaw".
+// An empty name means the time zone is unknown and is accepted.
+func ValidateTimeZone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if name == "Local" {
+		return ErrInvalidTimeZone
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return ErrInvalidTimeZone
+	}
+	return nil
+}
+
+var (
+	ErrSwiftCodeLength        = errors.New("swift code length must be 8 or 11 characters")
+	ErrInvalidInstitutionCode = errors.New("swift code institution code must consist of 4 letters")
+	ErrInvalidCountryCode     = errors.New("swift code country code must consist of 2 letters")
+	ErrInvalidLocationCode    = errors.New("swift code location code must consist of 2 letters or digits")
+	ErrInvalidBranchCode      = errors.New("swift code branch code must consist of 3 letters or digits")
+)
+
+const (
+	FormBIC8  = "BIC8"
+	FormBIC11 = "BIC11"
+)
+
 type SwiftCode struct {
-	s string
+	s    string
+	bic8 bool
 }
 
+// NewSwiftCode validates s against the ISO 9362 structure: a 4-letter institution code,
+// a 2-letter country code, a 2-character location code and a 3-character branch code.
+// The 8-character BIC8 form is accepted as well and refers to the headquarters,
+// so it is expanded with the XXX branch code.
 func NewSwiftCode(s string) (SwiftCode, error) {
+	s = strings.ToUpper(s)
+	bic8 := len(s) == 8
+	if bic8 {
+		s += "XXX"
+	}
 	if len(s) != 11 {
-		return SwiftCode{}, fmt.Errorf("swift code length must be 11 characters")
+		return SwiftCode{}, ErrSwiftCodeLength
+	}
+
+	switch {
+	case !isLetters(s[0:4]):
+		return SwiftCode{}, ErrInvalidInstitutionCode
+	case !isLetters(s[4:6]):
+		return SwiftCode{}, ErrInvalidCountryCode
+	case !isAlphanumeric(s[6:8]):
+		return SwiftCode{}, ErrInvalidLocationCode
+	case !isAlphanumeric(s[8:11]):
+		return SwiftCode{}, ErrInvalidBranchCode
 	}
-	return SwiftCode{s: strings.ToUpper(s)}, nil
+
+	return SwiftCode{s: s, bic8: bic8}, nil
+}
+
+func isLetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if (s[i] < 'A' || s[i] > 'Z') && (s[i] < '0' || s[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func (s SwiftCode) InstitutionCode() string {
+	return s.s[0:4]
 }
 
 func (s SwiftCode) CountryISO2() string {
 	return s.s[4:6]
 }
 
+func (s SwiftCode) LocationCode() string {
+	return s.s[6:8]
+}
+
+// IsTestBIC reports whether the code is a test and training BIC ("0" as the 8th character).
+func (s SwiftCode) IsTestBIC() bool {
+	return s.s[7] == '0'
+}
+
+// IsPassiveParticipant reports whether the code belongs to a passive participant
+// that is not connected to the SWIFT network ("1" as the 8th character).
+func (s SwiftCode) IsPassiveParticipant() bool {
+	return s.s[7] == '1'
+}
+
+// IsReverseBilling reports whether the receiver pays for messages sent to the code
+// ("2" as the 8th character).
+func (s SwiftCode) IsReverseBilling() bool {
+	return s.s[7] == '2'
+}
+
 func (s SwiftCode) BranchCode() string {
 	return s.s[8:11]
 }
@@ -80,6 +184,24 @@ func (s SwiftCode) HasHeadQuartersBranchCode() bool {
 	return s.BranchCode() == "XXX"
 }
 
+// IsBIC8 reports whether the code was given in its 8-character form.
+func (s SwiftCode) IsBIC8() bool {
+	return s.bic8
+}
+
+// Form returns FormBIC8 or FormBIC11 depending on how the code was given.
+func (s SwiftCode) Form() string {
+	if s.bic8 {
+		return FormBIC8
+	}
+	return FormBIC11
+}
+
+// Canonical returns the code in its 11-character form, forgetting how it was given.
+func (s SwiftCode) Canonical() SwiftCode {
+	return SwiftCode{s: s.s}
+}
+
 func (s SwiftCode) String() string {
 	return s.s
 }
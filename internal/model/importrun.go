@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// ImportRun records a single import of a CSV file into the database.
+type ImportRun struct {
+	Source      string
+	FileHash    string
+	RowsRead    int
+	RowsChanged int
+	ImportedAt  time.Time
+}
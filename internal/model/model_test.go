@@ -63,7 +63,16 @@ func TestNewBankUnit(t *testing.T) {
 			countryName: "POLAND",
 			address:     "Warsaw",
 			bankName:    "PKO Bank Polski",
-			wantErr:     "swift code length must be 11 characters",
+			wantErr:     "swift code length must be 8 or 11 characters",
+		},
+		{
+			name:        "invalid swift code structure",
+			swiftCode:   "12$%PL!!XXX",
+			countryISO2: "PL",
+			countryName: "POLAND",
+			address:     "Warsaw",
+			bankName:    "PKO Bank Polski",
+			wantErr:     "swift code institution code must consist of 4 letters",
 		},
 		{
 			name:        "country mismatch",
@@ -117,6 +126,47 @@ func TestNewBankUnit(t *testing.T) {
 	}
 }
 
+func TestNewSwiftCode(t *testing.T) {
+	tests := []struct {
+		name      string
+		swiftCode string
+		want      string
+		wantErr   error
+	}{
+		{name: "valid headquarter", swiftCode: "BPKOPLPWXXX", want: "BPKOPLPWXXX"},
+		{name: "valid branch with digits", swiftCode: "BREXPLPW022", want: "BREXPLPW022"},
+		{name: "digit in location code", swiftCode: "HYVEPLP2XXX", want: "HYVEPLP2XXX"},
+		{name: "lowercase is normalized", swiftCode: "bpkoplpwxxx", want: "BPKOPLPWXXX"},
+		{name: "BIC8 is expanded to headquarters", swiftCode: "BPKOPLPW", want: "BPKOPLPWXXX"},
+		{name: "too short", swiftCode: "BPKO", wantErr: model.ErrSwiftCodeLength},
+		{name: "between BIC8 and BIC11", swiftCode: "BPKOPLPW0", wantErr: model.ErrSwiftCodeLength},
+		{name: "invalid BIC8", swiftCode: "BPKOPL!W", wantErr: model.ErrInvalidLocationCode},
+		{name: "too long", swiftCode: "BPKOPLPWXXXX", wantErr: model.ErrSwiftCodeLength},
+		{name: "digit in institution code", swiftCode: "12KOPLPWXXX", wantErr: model.ErrInvalidInstitutionCode},
+		{name: "symbol in institution code", swiftCode: "BP$OPLPWXXX", wantErr: model.ErrInvalidInstitutionCode},
+		{name: "digit in country code", swiftCode: "BPKOP1PWXXX", wantErr: model.ErrInvalidCountryCode},
+		{name: "symbol in country code", swiftCode: "BPKO!!PWXXX", wantErr: model.ErrInvalidCountryCode},
+		{name: "symbol in location code", swiftCode: "BPKOPL!WXXX", wantErr: model.ErrInvalidLocationCode},
+		{name: "symbol in branch code", swiftCode: "BPKOPLPWXX-", wantErr: model.ErrInvalidBranchCode},
+		{name: "space in branch code", swiftCode: "BPKOPLPW X1", wantErr: model.ErrInvalidBranchCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := model.NewSwiftCode(tt.swiftCode)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
 func TestSwiftCodeHasHeadquartersBranchCode(t *testing.T) {
 	hq, err := model.NewSwiftCode("BPKOPLPWXXX")
 	assert.NoError(t, err)
@@ -134,8 +184,128 @@ func TestSwiftCodeBaseCodeAndBranchCode(t *testing.T) {
 	assert.Equal(t, "XXX", sc.BranchCode())
 }
 
+func TestSwiftCodeForm(t *testing.T) {
+	bic8, err := model.NewSwiftCode("BPKOPLPW")
+	assert.NoError(t, err)
+	assert.True(t, bic8.IsBIC8())
+	assert.Equal(t, model.FormBIC8, bic8.Form())
+	assert.True(t, bic8.HasHeadQuartersBranchCode())
+
+	bic11, err := model.NewSwiftCode("BPKOPLPWXXX")
+	assert.NoError(t, err)
+	assert.False(t, bic11.IsBIC8())
+	assert.Equal(t, model.FormBIC11, bic11.Form())
+
+	assert.Equal(t, bic11, bic8.Canonical())
+}
+
+func TestNewBankUnitFromBIC8(t *testing.T) {
+	bu, err := model.NewBankUnit("BPKOPLPW", "PL", "POLAND", "Warsaw", "PKO Bank Polski", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "BPKOPLPWXXX", bu.SwiftCode.String())
+	assert.False(t, bu.SwiftCode.IsBIC8())
+}
+
+func TestSwiftCodeLocationCode(t *testing.T) {
+	tests := []struct {
+		swiftCode          string
+		location           string
+		test               bool
+		passiveParticipant bool
+		reverseBilling     bool
+	}{
+		{swiftCode: "BPKOPLPWXXX", location: "PW"},
+		{swiftCode: "BPKOPLP0XXX", location: "P0", test: true},
+		{swiftCode: "BEFNBGS1XXX", location: "S1", passiveParticipant: true},
+		{swiftCode: "HYVEPLP2XXX", location: "P2", reverseBilling: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.swiftCode, func(t *testing.T) {
+			sc, err := model.NewSwiftCode(tt.swiftCode)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.location, sc.LocationCode())
+			assert.Equal(t, tt.test, sc.IsTestBIC())
+			assert.Equal(t, tt.passiveParticipant, sc.IsPassiveParticipant())
+			assert.Equal(t, tt.reverseBilling, sc.IsReverseBilling())
+		})
+	}
+}
+
+func TestSwiftCodeInstitutionCode(t *testing.T) {
+	sc, err := model.NewSwiftCode("BPKOPLPWXXX")
+	assert.NoError(t, err)
+	assert.Equal(t, "BPKO", sc.InstitutionCode())
+	assert.Equal(t, "PL", sc.CountryISO2())
+}
+
 func TestSwiftCodeString(t *testing.T) {
 	sc, err := model.NewSwiftCode("BPKOPLPWXXX")
 	assert.NoError(t, err)
 	assert.Equal(t, "BPKOPLPWXXX", sc.String())
 }
+
+func TestValidateTimeZone(t *testing.T) {
+	assert.NoError(t, model.ValidateTimeZone("Europe/Warsaw"))
+	assert.NoError(t, model.ValidateTimeZone("America/Montevideo"))
+	assert.NoError(t, model.ValidateTimeZone(""))
+	assert.ErrorIs(t, model.ValidateTimeZone("Europe/Warszawa"), model.ErrInvalidTimeZone)
+	assert.ErrorIs(t, model.ValidateTimeZone("Local"), model.ErrInvalidTimeZone)
+}
+
+func TestNewSwiftCodePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		err     error
+	}{
+		{pattern: "DEUT"},
+		{pattern: "deutde"},
+		{pattern: "DEUTDEFF"},
+		{pattern: "DEUTDEFF500"},
+		{pattern: "DEUT??FF"},
+		{pattern: "????DE"},
+		{pattern: "DEU", err: model.ErrPatternLength},
+		{pattern: "DEUTDEF", err: model.ErrPatternLength},
+		{pattern: "DEUTDEFF5000", err: model.ErrPatternLength},
+		{pattern: "DEU1", err: model.ErrInvalidPattern},
+		{pattern: "DEUTD1", err: model.ErrInvalidPattern},
+		{pattern: "DEUTDEF*", err: model.ErrInvalidPattern},
+		{pattern: "DEUT%%FF", err: model.ErrInvalidPattern},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			_, err := model.NewSwiftCodePattern(tt.pattern)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestSwiftCodePatternMatches(t *testing.T) {
+	sc, err := model.NewSwiftCode("DEUTDEFF500")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		pattern  string
+		baseCode string
+		matches  bool
+	}{
+		{pattern: "DEUT", matches: true},
+		{pattern: "DEUTDE", matches: true},
+		{pattern: "DEUTDEFF", baseCode: "DEUTDEFF", matches: true},
+		{pattern: "DEUTDEFF500", baseCode: "DEUTDEFF", matches: true},
+		{pattern: "DEUTDEFFXXX", baseCode: "DEUTDEFF", matches: false},
+		{pattern: "DEUT??FF", matches: true},
+		{pattern: "DEUTDEFF5??", baseCode: "DEUTDEFF", matches: true},
+		{pattern: "????PL", matches: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			p, err := model.NewSwiftCodePattern(tt.pattern)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.matches, p.Matches(sc))
+			assert.Equal(t, tt.baseCode, p.BaseCode())
+		})
+	}
+}
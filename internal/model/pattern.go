@@ -0,0 +1,67 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrPatternLength  = errors.New("pattern length must be 4, 6, 8 or 11 characters")
+	ErrInvalidPattern = errors.New("pattern must follow the swift code structure, with ? matching any character")
+)
+
+const patternWildcard = '?'
+
+// SwiftCodePattern matches swift codes by prefix: an institution code (4 characters),
+// institution and country code (6), base code (8) or a full swift code (11).
+// A '?' matches any single character.
+type SwiftCodePattern struct {
+	s string
+}
+
+func NewSwiftCodePattern(s string) (SwiftCodePattern, error) {
+	s = strings.ToUpper(s)
+	if len(s) != 4 && len(s) != 6 && len(s) != 8 && len(s) != 11 {
+		return SwiftCodePattern{}, ErrPatternLength
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == patternWildcard {
+			continue
+		}
+		// institution and country codes are letters only
+		if i < 6 && !isLetters(s[i:i+1]) || !isAlphanumeric(s[i:i+1]) {
+			return SwiftCodePattern{}, ErrInvalidPattern
+		}
+	}
+
+	return SwiftCodePattern{s: s}, nil
+}
+
+func (p SwiftCodePattern) HasWildcards() bool {
+	return strings.IndexByte(p.s, patternWildcard) >= 0
+}
+
+// BaseCode returns the 8-character prefix every matching swift code starts with,
+// or an empty string when the pattern is shorter or has wildcards in it.
+func (p SwiftCodePattern) BaseCode() string {
+	if len(p.s) < 8 || strings.IndexByte(p.s[:8], patternWildcard) >= 0 {
+		return ""
+	}
+	return p.s[:8]
+}
+
+func (p SwiftCodePattern) Matches(sc SwiftCode) bool {
+	code := sc.String()
+	for i := 0; i < len(p.s); i++ {
+		if p.s[i] != patternWildcard && p.s[i] != code[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p SwiftCodePattern) String() string {
+	return p.s
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+type APIKeyRepo struct {
+	db DB
+}
+
+func NewAPIKeyRepo(db DB) *APIKeyRepo {
+	return &APIKeyRepo{db: db}
+}
+
+type apiKeyRecord struct {
+	ID          int64      `db:"id"`
+	Prefix      string     `db:"prefix"`
+	SecretHash  string     `db:"secret_hash"`
+	Owner       string     `db:"owner"`
+	Scopes      []string   `db:"scopes"`
+	CreatedAt   time.Time  `db:"created_at"`
+	ExpiresAt   *time.Time `db:"expires_at"`
+	LastUsedAt  *time.Time `db:"last_used_at"`
+	RevokedAt   *time.Time `db:"revoked_at"`
+	RotatedFrom *int64     `db:"rotated_from"`
+}
+
+func (rec *apiKeyRecord) toModel() *model.APIKey {
+	return &model.APIKey{
+		ID:          rec.ID,
+		Prefix:      rec.Prefix,
+		Owner:       rec.Owner,
+		Scopes:      rec.Scopes,
+		CreatedAt:   rec.CreatedAt,
+		ExpiresAt:   rec.ExpiresAt,
+		LastUsedAt:  rec.LastUsedAt,
+		RevokedAt:   rec.RevokedAt,
+		RotatedFrom: rec.RotatedFrom,
+	}
+}
+
+// activeAPIKey matches keys that are neither revoked nor expired.
+const activeAPIKey = `revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now())`
+
+func (r *APIKeyRepo) Create(ctx context.Context, key *model.APIKey, secretHash string) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO api_keys (prefix, secret_hash, owner, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`,
+		key.Prefix, secretHash, key.Owner, key.Scopes, key.ExpiresAt,
+	).Scan(&key.ID, &key.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return repo.ErrDuplicate
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepo) Use(ctx context.Context, secretHash string) (*model.APIKey, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE api_keys SET last_used_at = now()
+		WHERE secret_hash = $1 AND `+activeAPIKey+`
+		RETURNING *`,
+		secretHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use api key: %w", err)
+	}
+	return collectAPIKey(rows)
+}
+
+func (r *APIKeyRepo) List(ctx context.Context, includeRevoked bool) ([]*model.APIKey, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT * FROM api_keys
+		WHERE $1 OR revoked_at IS NULL
+		ORDER BY owner, id`,
+		includeRevoked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	records, err := pgx.CollectRows(rows, pgx.RowToStructByName[apiKeyRecord])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect api keys: %w", err)
+	}
+
+	keys := make([]*model.APIKey, len(records))
+	for i, rec := range records {
+		keys[i] = rec.toModel()
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepo) Rotate(ctx context.Context, id int64, prefix, secretHash string) (*model.APIKey, error) {
+	var rotated *model.APIKey
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			UPDATE api_keys SET revoked_at = now()
+			WHERE id = $1 AND `+activeAPIKey+`
+			RETURNING *`,
+			id)
+		if err != nil {
+			return fmt.Errorf("failed to revoke api key: %w", err)
+		}
+		old, err := collectAPIKey(rows)
+		if err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx, `
+			INSERT INTO api_keys (prefix, secret_hash, owner, scopes, expires_at, rotated_from)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING *`,
+			prefix, secretHash, old.Owner, old.Scopes, old.ExpiresAt, old.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create api key: %w", err)
+		}
+		rotated, err = collectAPIKey(rows)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return repo.ErrDuplicate
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rotated, nil
+}
+
+func (r *APIKeyRepo) Revoke(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE api_keys SET revoked_at = now()
+		WHERE id = $1 AND revoked_at IS NULL`,
+		id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+func collectAPIKey(rows pgx.Rows) (*model.APIKey, error) {
+	rec, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[apiKeyRecord])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect api key: %w", err)
+	}
+	return rec.toModel(), nil
+}
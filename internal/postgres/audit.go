@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkarmon/swiftcodes/internal/audit"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+type AuditRepo struct {
+	db DB
+}
+
+func NewAuditRepo(db DB) *AuditRepo {
+	return &AuditRepo{db: db}
+}
+
+// bankUnitSnapshot is the JSON form of a bank unit stored in the before and after
+// columns of audit_events.
+type bankUnitSnapshot struct {
+	SwiftCode     string `json:"swiftCode"`
+	CountryISO2   string `json:"countryISO2"`
+	CountryName   string `json:"countryName"`
+	Name          string `json:"bankName"`
+	Address       string `json:"address"`
+	IsHeadquarter bool   `json:"isHeadquarter"`
+	CodeType      string `json:"codeType,omitempty"`
+	TownName      string `json:"townName,omitempty"`
+	TimeZone      string `json:"timeZone,omitempty"`
+}
+
+func snapshotOf(bankUnit *model.BankUnit) *bankUnitSnapshot {
+	if bankUnit == nil {
+		return nil
+	}
+	return &bankUnitSnapshot{
+		SwiftCode:     bankUnit.SwiftCode.String(),
+		CountryISO2:   bankUnit.Country.Code.String(),
+		CountryName:   bankUnit.Country.Name,
+		Name:          bankUnit.Name,
+		Address:       bankUnit.Address,
+		IsHeadquarter: bankUnit.IsHeadquarter,
+		CodeType:      bankUnit.CodeType,
+		TownName:      bankUnit.TownName,
+		TimeZone:      bankUnit.TimeZone,
+	}
+}
+
+func (s *bankUnitSnapshot) toModel() (*model.BankUnit, error) {
+	if s == nil {
+		return nil, nil
+	}
+	unit, err := model.NewBankUnit(s.SwiftCode, s.CountryISO2, s.CountryName, s.Address, s.Name, s.IsHeadquarter)
+	if err != nil {
+		return nil, err
+	}
+	unit.CodeType = s.CodeType
+	unit.TownName = s.TownName
+	unit.TimeZone = s.TimeZone
+	return unit, nil
+}
+
+// auditChange is a change to be recorded by recordAuditEvents.
+type auditChange struct {
+	action model.AuditAction
+	before *model.BankUnit
+	after  *model.BankUnit
+}
+
+func (c auditChange) swiftCode() string {
+	if c.after != nil {
+		return c.after.SwiftCode.String()
+	}
+	return c.before.SwiftCode.String()
+}
+
+// recordAuditEvents writes one audit event per change within tx, so the events are
+// committed or rolled back together with the changes. The actor and request id are
+// taken from ctx.
+func recordAuditEvents(ctx context.Context, tx pgx.Tx, changes []auditChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	actor, requestID := audit.Actor(ctx), audit.RequestID(ctx)
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"audit_events"},
+		[]string{"actor", "action", "swift_code", "request_id", "before", "after"},
+		pgx.CopyFromSlice(len(changes), func(i int) ([]any, error) {
+			c := changes[i]
+			return []any{
+				actor, string(c.action), c.swiftCode(), requestID, snapshotOf(c.before), snapshotOf(c.after),
+			}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("failed to record audit events: %w", err)
+	}
+	return nil
+}
+
+type auditEventRecord struct {
+	ID         int64             `db:"id"`
+	OccurredAt time.Time         `db:"occurred_at"`
+	Actor      string            `db:"actor"`
+	Action     string            `db:"action"`
+	SwiftCode  string            `db:"swift_code"`
+	RequestID  string            `db:"request_id"`
+	Before     *bankUnitSnapshot `db:"before"`
+	After      *bankUnitSnapshot `db:"after"`
+}
+
+func (rec *auditEventRecord) toModel() (*model.AuditEvent, error) {
+	swiftCode, err := model.NewSwiftCode(rec.SwiftCode)
+	if err != nil {
+		return nil, err
+	}
+	before, err := rec.Before.toModel()
+	if err != nil {
+		return nil, err
+	}
+	after, err := rec.After.toModel()
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.AuditEvent{
+		ID:         rec.ID,
+		OccurredAt: rec.OccurredAt,
+		Actor:      rec.Actor,
+		Action:     model.AuditAction(rec.Action),
+		SwiftCode:  swiftCode,
+		RequestID:  rec.RequestID,
+		Before:     before,
+		After:      after,
+	}, nil
+}
+
+func (r *AuditRepo) List(ctx context.Context, filter repo.AuditFilter) ([]*model.AuditEvent, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT * FROM audit_events
+		WHERE ($1 = '' OR swift_code = $1)
+			AND ($2 = '' OR actor = $2)
+			AND ($3::timestamptz IS NULL OR occurred_at >= $3)
+			AND ($4::timestamptz IS NULL OR occurred_at < $4)
+			AND ($5::bigint = 0 OR id < $5)
+		ORDER BY id DESC
+		LIMIT $6`,
+		filter.SwiftCode, filter.Actor, timeArg(filter.From), timeArg(filter.To), filter.BeforeID, limitArg(filter.Limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	records, err := pgx.CollectRows(rows, pgx.RowToStructByName[auditEventRecord])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect audit events: %w", err)
+	}
+
+	events := make([]*model.AuditEvent, len(records))
+	for i, rec := range records {
+		event, err := rec.toModel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to map audit event record: %w", err)
+		}
+		events[i] = event
+	}
+
+	return events, nil
+}
+
+// timeArg turns a zero time into NULL.
+func timeArg(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
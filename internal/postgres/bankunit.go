@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"iter"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
@@ -21,17 +24,23 @@ func NewBankUnitRepo(db DB) *BankUnitRepo {
 }
 
 type bankUnitRecord struct {
-	ID            int    `db:"id"`
-	CountryISO2   string `db:"country_iso2"`
-	CountryName   string `db:"country_name"`
-	SwiftCode     string `db:"swift_code"`
-	Name          string `db:"bank_name"`
-	Address       string `db:"address"`
-	IsHeadquarter bool   `db:"is_headquarter"`
+	ID            int        `db:"id"`
+	CountryISO2   string     `db:"country_iso2"`
+	CountryName   string     `db:"country_name"`
+	SwiftCode     string     `db:"swift_code"`
+	Name          string     `db:"bank_name"`
+	Address       string     `db:"address"`
+	IsHeadquarter bool       `db:"is_headquarter"`
+	CodeType      string     `db:"code_type"`
+	TownName      string     `db:"town_name"`
+	TimeZone      string     `db:"time_zone"`
+	DeletedAt     *time.Time `db:"deleted_at"`
+	ValidFrom     time.Time  `db:"valid_from"`
+	ValidTo       *time.Time `db:"valid_to"`
 }
 
 func (rec *bankUnitRecord) toModel() (*model.BankUnit, error) {
-	return model.NewBankUnit(
+	unit, err := model.NewBankUnit(
 		rec.SwiftCode,
 		rec.CountryISO2,
 		rec.CountryName,
@@ -39,55 +48,290 @@ func (rec *bankUnitRecord) toModel() (*model.BankUnit, error) {
 		rec.Name,
 		rec.IsHeadquarter,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	unit.CodeType = rec.CodeType
+	unit.TownName = rec.TownName
+	unit.TimeZone = rec.TimeZone
+	unit.DeletedAt = rec.DeletedAt
+	unit.ValidFrom = rec.ValidFrom
+	unit.ValidTo = rec.ValidTo
+	return unit, nil
+}
+
+var bankUnitColumns = []string{
+	"country_iso2", "swift_code", "name", "address", "is_headquarter", "code_type", "town_name", "time_zone",
+}
+
+func bankUnitRow(bankUnit *model.BankUnit) []any {
+	return []any{
+		bankUnit.Country.Code.String(), bankUnit.SwiftCode.String(), bankUnit.Name, bankUnit.Address, bankUnit.IsHeadquarter,
+		bankUnit.CodeType, bankUnit.TownName, bankUnit.TimeZone,
+	}
+}
+
+func bankUnitRows(bankUnits []*model.BankUnit) [][]any {
+	rows := make([][]any, len(bankUnits))
+	for i, bankUnit := range bankUnits {
+		rows[i] = bankUnitRow(bankUnit)
+	}
+	return rows
+}
+
+// bankUnitSource adapts a bank unit iterator to pgx.CopyFromSource. pgx pulls rows
+// while filling its send buffer, so only one buffer worth of rows is held in memory.
+type bankUnitSource struct {
+	next func() (*model.BankUnit, error, bool)
+	row  []any
+	err  error
+}
+
+func (s *bankUnitSource) Next() bool {
+	bankUnit, err, ok := s.next()
+	if !ok {
+		return false
+	}
+	if err != nil {
+		s.err = err
+		return false
+	}
+	s.row = bankUnitRow(bankUnit)
+	return true
+}
+
+func (s *bankUnitSource) Values() ([]any, error) {
+	return s.row, nil
+}
+
+func (s *bankUnitSource) Err() error {
+	return s.err
+}
+
+// copyBankUnits streams bank units into table with COPY.
+func copyBankUnits(ctx context.Context, tx pgx.Tx, table string, bankUnits iter.Seq2[*model.BankUnit, error]) (int, error) {
+	next, stop := iter.Pull2(bankUnits)
+	defer stop()
+
+	src := &bankUnitSource{next: next}
+	n, err := tx.CopyFrom(ctx, pgx.Identifier{table}, bankUnitColumns, src)
+	if src.err != nil {
+		return 0, src.err
+	}
+	if violation := constraintViolation(err); violation != nil {
+		return 0, violation
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy bank units: %w", err)
+	}
+	return int(n), nil
 }
 
 func (r *BankUnitRepo) BulkCreate(ctx context.Context, bankUnits []*model.BankUnit) error {
 	return r.db.InTx(ctx, func(tx pgx.Tx) error {
-		rows := make([][]any, len(bankUnits))
-		for i, bankUnit := range bankUnits {
-			rows[i] = []any{bankUnit.Country.Code.String(), bankUnit.SwiftCode.String(), bankUnit.Name, bankUnit.Address, bankUnit.IsHeadquarter}
+		_, err := tx.CopyFrom(ctx, pgx.Identifier{"bank_units"}, bankUnitColumns, pgx.CopyFromRows(bankUnitRows(bankUnits)))
+		if violation := constraintViolation(err); violation != nil {
+			return violation
 		}
-
-		_, err := tx.CopyFrom(ctx, pgx.Identifier{"bank_units"},
-			[]string{"country_iso2", "swift_code", "name", "address", "is_headquarter"}, pgx.CopyFromRows(rows))
 		if err != nil {
 			return fmt.Errorf("failed to copy bank units: %w", err)
 		}
-		return nil
+		return recordAuditEvents(ctx, tx, createdChanges(bankUnits))
 	})
 }
 
-func (r *BankUnitRepo) Create(ctx context.Context, bankUnit *model.BankUnit) error {
-	_, err := r.db.Exec(ctx, `
-		INSERT 
-		INTO bank_units
-		(country_iso2, swift_code, name, address, is_headquarter)
-		VALUES ($1, $2, $3, $4, $5)`,
-		bankUnit.Country.Code.String(), bankUnit.SwiftCode.String(), bankUnit.Name, bankUnit.Address, bankUnit.IsHeadquarter)
-
+func (r *BankUnitRepo) BulkCreateFrom(ctx context.Context, bankUnits iter.Seq2[*model.BankUnit, error]) (int, error) {
+	var created int
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		created, err = copyBankUnits(ctx, tx, "bank_units", bankUnits)
+		return err
+	})
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
-			return repo.ErrDuplicate
-		}
-		return fmt.Errorf("failed to create bank unit: %w", err)
+		return 0, err
 	}
-	return nil
+	return created, nil
 }
 
-func (r *BankUnitRepo) Delete(ctx context.Context, swiftCode model.SwiftCode) error {
-	_, err := r.db.Exec(ctx, "DELETE FROM bank_units WHERE swift_code = $1", swiftCode.String())
+func (r *BankUnitRepo) BulkUpsert(ctx context.Context, bankUnits iter.Seq2[*model.BankUnit, error]) (int, error) {
+	var changed int
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			CREATE TEMP TABLE bank_units_staging (
+				country_iso2 CHAR(2),
+				swift_code CHAR(11),
+				name VARCHAR(255),
+				address TEXT,
+				is_headquarter BOOLEAN,
+				code_type TEXT,
+				town_name VARCHAR(255),
+				time_zone VARCHAR(64)
+			) ON COMMIT DROP`)
+		if err != nil {
+			return fmt.Errorf("failed to create staging table: %w", err)
+		}
+
+		if _, err := copyBankUnits(ctx, tx, "bank_units_staging", bankUnits); err != nil {
+			return err
+		}
+
+		// Changed bank units get their current version closed and a new one inserted,
+		// new bank units are inserted. Unchanged ones are left alone.
+		tag, err := tx.Exec(ctx, `
+			WITH incoming AS (
+				SELECT DISTINCT ON (swift_code)
+					country_iso2, swift_code, name, address, is_headquarter, code_type, town_name, time_zone
+				FROM bank_units_staging
+			), closed AS (
+				UPDATE bank_units bu SET valid_to = now()
+				FROM incoming i
+				WHERE bu.swift_code = i.swift_code AND bu.valid_to IS NULL AND bu.deleted_at IS NULL
+					AND (bu.country_iso2, bu.name, bu.address, bu.is_headquarter,
+						bu.code_type, bu.town_name, bu.time_zone)
+					IS DISTINCT FROM
+					(i.country_iso2, i.name, i.address, i.is_headquarter,
+						i.code_type, i.town_name, i.time_zone)
+				RETURNING bu.swift_code
+			)
+			INSERT INTO bank_units
+			(country_iso2, swift_code, name, address, is_headquarter, code_type, town_name, time_zone)
+			SELECT i.country_iso2, i.swift_code, i.name, i.address, i.is_headquarter, i.code_type, i.town_name, i.time_zone
+			FROM incoming i
+			WHERE i.swift_code IN (SELECT swift_code FROM closed)
+				OR NOT EXISTS (
+					SELECT 1 FROM bank_units bu
+					WHERE bu.swift_code = i.swift_code AND bu.valid_to IS NULL AND bu.deleted_at IS NULL
+				)`)
+		if violation := constraintViolation(err); violation != nil {
+			return violation
+		}
+		if err != nil {
+			return fmt.Errorf("failed to upsert bank units: %w", err)
+		}
+		changed = int(tag.RowsAffected())
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete bank unit: %w", err)
+		return 0, err
 	}
-	return nil
+
+	return changed, nil
+}
+
+func (r *BankUnitRepo) Create(ctx context.Context, bankUnit *model.BankUnit) error {
+	return r.db.InTx(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT 
+			INTO bank_units
+			(country_iso2, swift_code, name, address, is_headquarter, code_type, town_name, time_zone)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			bankUnitRow(bankUnit)...)
+
+		if violation := constraintViolation(err); violation != nil {
+			return violation
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create bank unit: %w", err)
+		}
+		return recordAuditEvents(ctx, tx, createdChanges([]*model.BankUnit{bankUnit}))
+	})
+}
+
+// updateBankUnitSQL closes the current version of the bank unit with a matching swift code
+// and inserts a new version with the given fields, it takes the arguments returned by bankUnitRow.
+const updateBankUnitSQL = `
+	WITH closed AS (
+		UPDATE bank_units SET valid_to = now()
+		WHERE swift_code = $2 AND valid_to IS NULL AND deleted_at IS NULL
+		RETURNING swift_code
+	)
+	INSERT INTO bank_units
+	(country_iso2, swift_code, name, address, is_headquarter, code_type, town_name, time_zone)
+	SELECT $1, swift_code, $3, $4, $5, $6, $7, $8 FROM closed`
+
+func (r *BankUnitRepo) Update(ctx context.Context, bankUnit *model.BankUnit) error {
+	return r.db.InTx(ctx, func(tx pgx.Tx) error {
+		before, err := r.lockBankUnits(ctx, tx, "swift_code = $1", bankUnit.SwiftCode.String())
+		if err != nil {
+			return err
+		}
+		if len(before) == 0 {
+			return repo.ErrNotFound
+		}
+
+		_, err = tx.Exec(ctx, updateBankUnitSQL, bankUnitRow(bankUnit)...)
+		if violation := constraintViolation(err); violation != nil {
+			return violation
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update bank unit: %w", err)
+		}
+		return recordAuditEvents(ctx, tx, []auditChange{{action: model.AuditUpdate, before: before[0], after: bankUnit}})
+	})
 }
 
-func (r *BankUnitRepo) GetBySwiftCode(ctx context.Context, swiftCode model.SwiftCode) (*model.BankUnit, error) {
+func (r *BankUnitRepo) Delete(ctx context.Context, swiftCode model.SwiftCode, opts repo.DeleteOptions) error {
+	return r.db.InTx(ctx, func(tx pgx.Tx) error {
+		units, err := r.lockBankUnits(ctx, tx, "swift_code = $1", swiftCode.String())
+		if err != nil {
+			return err
+		}
+		if len(units) == 0 {
+			return repo.ErrNotFound
+		}
+		isHeadquarter := units[0].IsHeadquarter
+
+		switch {
+		case isHeadquarter && opts.Cascade:
+			branches, err := r.lockBankUnits(ctx, tx, "LEFT(swift_code, 8) = $1 AND swift_code != $2",
+				swiftCode.BaseCode(), swiftCode.String())
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx,
+				`UPDATE bank_units SET deleted_at = now()
+				WHERE LEFT(swift_code, 8) = $1 AND swift_code != $2 AND valid_to IS NULL AND deleted_at IS NULL`,
+				swiftCode.BaseCode(), swiftCode.String())
+			if err != nil {
+				return fmt.Errorf("failed to delete branches: %w", err)
+			}
+			units = append(units, branches...)
+		case isHeadquarter:
+			var hasBranches bool
+			err := tx.QueryRow(ctx, `
+				SELECT EXISTS(
+					SELECT 1 FROM bank_units
+					WHERE LEFT(swift_code, 8) = $1 AND swift_code != $2 AND valid_to IS NULL AND deleted_at IS NULL
+				)`,
+				swiftCode.BaseCode(), swiftCode.String()).Scan(&hasBranches)
+			if err != nil {
+				return fmt.Errorf("failed to check branches: %w", err)
+			}
+			if hasBranches {
+				return repo.ErrHasBranches
+			}
+		}
+
+		_, err = tx.Exec(ctx,
+			"UPDATE bank_units SET deleted_at = now() WHERE swift_code = $1 AND valid_to IS NULL AND deleted_at IS NULL",
+			swiftCode.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete bank unit: %w", err)
+		}
+		return recordAuditEvents(ctx, tx, deletedChanges(units))
+	})
+}
+
+func (r *BankUnitRepo) GetBySwiftCode(ctx context.Context, swiftCode model.SwiftCode, opts repo.ReadOptions) (*model.BankUnit, error) {
+	args := []any{swiftCode.String(), opts.IncludeDeleted}
+	source := bankUnitsAt(opts.AsOf, &args)
 	rows, err := r.db.Query(ctx, `
-		SELECT * FROM bank_units_with_country
-		WHERE swift_code = $1`,
-		swiftCode.String())
+		SELECT * FROM `+source+`
+		WHERE swift_code = $1 AND ($2 OR deleted_at IS NULL)
+		ORDER BY deleted_at DESC NULLS FIRST
+		LIMIT 1`,
+		args...)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bank unit: %w", err)
@@ -109,20 +353,125 @@ func (r *BankUnitRepo) GetBySwiftCode(ctx context.Context, swiftCode model.Swift
 	return unit, nil
 }
 
-func (r *BankUnitRepo) GetAllByCountry(ctx context.Context, countryISO2 model.CountryISO2) ([]*model.BankUnit, error) {
+func (r *BankUnitRepo) Restore(ctx context.Context, swiftCode model.SwiftCode) (*model.BankUnit, error) {
+	var restored *model.BankUnit
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		// the deleted version is kept as history and a live copy of it becomes the current version
+		var id int
+		err := tx.QueryRow(ctx, `
+			WITH closed AS (
+				UPDATE bank_units SET valid_to = now()
+				WHERE id = (
+					SELECT id FROM bank_units
+					WHERE swift_code = $1 AND valid_to IS NULL AND deleted_at IS NOT NULL
+					ORDER BY deleted_at DESC
+					LIMIT 1
+				)
+				RETURNING country_iso2, swift_code, name, address, is_headquarter, code_type, town_name, time_zone
+			)
+			INSERT INTO bank_units
+			(country_iso2, swift_code, name, address, is_headquarter, code_type, town_name, time_zone)
+			SELECT * FROM closed
+			RETURNING id`,
+			swiftCode.String()).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.ErrNotFound
+		}
+		if violation := constraintViolation(err); violation != nil {
+			return violation
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restore bank unit: %w", err)
+		}
+
+		units, err := r.lockBankUnits(ctx, tx, "id = $1", id)
+		if err != nil {
+			return err
+		}
+		restored = units[0]
+		return recordAuditEvents(ctx, tx, []auditChange{{action: model.AuditRestore, after: restored}})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+func (r *BankUnitRepo) GetDeleted(ctx context.Context, opts repo.DeletedListOptions) ([]*model.BankUnit, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT * FROM bank_units_with_country
+		WHERE deleted_at IS NOT NULL AND deleted_at >= $1
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $2`,
+		opts.Since, limitArg(opts.Limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted bank units: %w", err)
+	}
+	return r.fromRowsToModels(rows)
+}
+
+func (r *BankUnitRepo) GetBySwiftCodes(ctx context.Context, swiftCodes []model.SwiftCode) ([]*model.BankUnit, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT * FROM bank_units_with_country
-		WHERE country_iso2 = $1
+		WHERE swift_code = ANY($1) AND deleted_at IS NULL`,
+		swiftCodeStrings(swiftCodes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bank units: %w", err)
+	}
+	return r.fromRowsToModels(rows)
+}
+
+func (r *BankUnitRepo) GetAllByCountry(ctx context.Context, countryISO2 model.CountryISO2, opts repo.ListOptions) ([]*model.BankUnit, error) {
+	args := []any{countryISO2.String(), opts.ExcludeTestBICs, opts.After, limitArg(opts.Limit)}
+	source := bankUnitsAt(opts.AsOf, &args)
+	rows, err := r.db.Query(ctx, `
+		SELECT * FROM `+source+`
+		WHERE country_iso2 = $1 AND deleted_at IS NULL
+		AND (NOT $2 OR SUBSTRING(swift_code, 8, 1) <> '0')
+		AND swift_code > $3
+		ORDER BY swift_code
+		LIMIT $4
 		`,
-		countryISO2.String())
+		args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list bank units: %w", err)
 	}
 	return r.fromRowsToModels(rows)
 }
 
-func (r *BankUnitRepo) GetAll(ctx context.Context) ([]*model.BankUnit, error) {
-	rows, err := r.db.Query(ctx, `SELECT * FROM bank_units_with_country`)
+func (r *BankUnitRepo) FindByPattern(ctx context.Context, pattern model.SwiftCodePattern, opts repo.ListOptions) ([]*model.BankUnit, error) {
+	like := strings.ReplaceAll(pattern.String(), "?", "_")
+	if len(like) < 11 {
+		like += "%"
+	}
+
+	args := []any{like, opts.ExcludeTestBICs, opts.After, limitArg(opts.Limit)}
+	baseCodeCond := ""
+	// matching on the base code lets postgres use the LEFT(swift_code, 8) index
+	if baseCode := pattern.BaseCode(); baseCode != "" {
+		baseCodeCond = "AND LEFT(swift_code, 8) = $5"
+		args = append(args, baseCode)
+	}
+
+	source := bankUnitsAt(opts.AsOf, &args)
+	rows, err := r.db.Query(ctx, `
+		SELECT * FROM `+source+`
+		WHERE swift_code LIKE $1 AND deleted_at IS NULL `+baseCodeCond+`
+		AND (NOT $2 OR SUBSTRING(swift_code, 8, 1) <> '0')
+		AND swift_code > $3
+		ORDER BY swift_code
+		LIMIT $4
+		`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find bank units by pattern: %w", err)
+	}
+	return r.fromRowsToModels(rows)
+}
+
+func (r *BankUnitRepo) GetAll(ctx context.Context, opts repo.ReadOptions) ([]*model.BankUnit, error) {
+	rows, err := r.db.Query(ctx, `SELECT * FROM bank_units_with_country WHERE $1 OR deleted_at IS NULL`, opts.IncludeDeleted)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all bank units: %w", err)
 	}
@@ -130,12 +479,31 @@ func (r *BankUnitRepo) GetAll(ctx context.Context) ([]*model.BankUnit, error) {
 	return r.fromRowsToModels(rows)
 }
 
-func (r *BankUnitRepo) GetBranches(ctx context.Context, swiftCode model.SwiftCode) ([]*model.BankUnit, error) {
+func (r *BankUnitRepo) GetBranches(ctx context.Context, swiftCode model.SwiftCode, opts repo.ReadOptions) ([]*model.BankUnit, error) {
+	args := []any{swiftCode.BaseCode(), swiftCode.String(), opts.IncludeDeleted}
+	source := bankUnitsAt(opts.AsOf, &args)
 	rows, err := r.db.Query(ctx, `
-		SELECT * FROM bank_units_with_country
-		WHERE LEFT(swift_code, 8) = $1 AND swift_code != $2
-	`, swiftCode.BaseCode(), swiftCode.String())
+		SELECT * FROM `+source+`
+		WHERE LEFT(swift_code, 8) = $1 AND swift_code != $2 AND ($3 OR deleted_at IS NULL)
+	`, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get branches: %w", err)
+	}
+
+	return r.fromRowsToModels(rows)
+}
+
+func (r *BankUnitRepo) GetBranchesOf(ctx context.Context, headquarters []model.SwiftCode) ([]*model.BankUnit, error) {
+	baseCodes := make([]string, len(headquarters))
+	for i, hq := range headquarters {
+		baseCodes[i] = hq.BaseCode()
+	}
 
+	rows, err := r.db.Query(ctx, `
+		SELECT * FROM bank_units_with_country
+		WHERE LEFT(swift_code, 8) = ANY($1) AND NOT swift_code = ANY($2) AND deleted_at IS NULL
+	`, baseCodes, swiftCodeStrings(headquarters))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get branches: %w", err)
 	}
@@ -151,6 +519,190 @@ func (r *BankUnitRepo) DeleteAll(ctx context.Context) error {
 	return nil
 }
 
+func (r *BankUnitRepo) ApplyChangeset(ctx context.Context, changes repo.Changeset) error {
+	return r.db.InTx(ctx, func(tx pgx.Tx) error {
+		var audited []auditChange
+		if len(changes.Delete) > 0 {
+			codes := swiftCodeStrings(changes.Delete)
+			deleted, err := r.lockBankUnits(ctx, tx, "swift_code = ANY($1)", codes)
+			if err != nil {
+				return err
+			}
+			audited = append(audited, deletedChanges(deleted)...)
+
+			_, err = tx.Exec(ctx,
+				`UPDATE bank_units SET deleted_at = now()
+				WHERE swift_code = ANY($1) AND valid_to IS NULL AND deleted_at IS NULL`,
+				codes)
+			if err != nil {
+				return fmt.Errorf("failed to delete bank units: %w", err)
+			}
+		}
+
+		for _, bankUnit := range changes.Update {
+			before, err := r.lockBankUnits(ctx, tx, "swift_code = $1", bankUnit.SwiftCode.String())
+			if err != nil {
+				return err
+			}
+			if len(before) == 0 {
+				return fmt.Errorf("failed to update bank unit %s: %w", bankUnit.SwiftCode, repo.ErrNotFound)
+			}
+			_, err = tx.Exec(ctx, updateBankUnitSQL, bankUnitRow(bankUnit)...)
+			if violation := constraintViolation(err); violation != nil {
+				err = violation
+			}
+			if err != nil {
+				return fmt.Errorf("failed to update bank unit %s: %w", bankUnit.SwiftCode, err)
+			}
+			audited = append(audited, auditChange{action: model.AuditUpdate, before: before[0], after: bankUnit})
+		}
+
+		if len(changes.Create) > 0 {
+			_, err := tx.CopyFrom(ctx, pgx.Identifier{"bank_units"}, bankUnitColumns, pgx.CopyFromRows(bankUnitRows(changes.Create)))
+			if violation := constraintViolation(err); violation != nil {
+				return violation
+			}
+			if err != nil {
+				return fmt.Errorf("failed to copy bank units: %w", err)
+			}
+			audited = append(audited, createdChanges(changes.Create)...)
+		}
+
+		return recordAuditEvents(ctx, tx, audited)
+	})
+}
+
+type searchResultRecord struct {
+	bankUnitRecord
+	Score float64 `db:"score"`
+}
+
+// Search combines full text search with trigram word similarity, so that both whole
+// words and misspelled or partial ones match. Accents are ignored on both sides.
+func (r *BankUnitRepo) Search(ctx context.Context, query repo.SearchQuery) ([]repo.SearchResult, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH q AS (
+			SELECT
+				websearch_to_tsquery('simple', immutable_unaccent($1)) AS tsq,
+				immutable_unaccent(lower($1)) AS term
+		)
+		SELECT v.*, (
+			ts_rank(bu.search_document, q.tsq) + GREATEST(
+				word_similarity(q.term, immutable_unaccent(lower(bu.name))),
+				word_similarity(q.term, immutable_unaccent(lower(bu.address)))
+			)
+		)::float8 AS score
+		FROM bank_units_with_country v
+		JOIN bank_units bu ON bu.id = v.id
+		CROSS JOIN q
+		WHERE (
+			bu.search_document @@ q.tsq
+			OR q.term <% immutable_unaccent(lower(bu.name))
+			OR q.term <% immutable_unaccent(lower(bu.address))
+		)
+		AND v.deleted_at IS NULL
+		AND ($2::text = '' OR v.country_iso2 = $2)
+		AND (NOT $3 OR v.is_headquarter)
+		ORDER BY score DESC, v.swift_code
+		LIMIT $4`,
+		query.Text, query.CountryISO2.String(), query.HeadquartersOnly, limitArg(query.Limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search bank units: %w", err)
+	}
+
+	records, err := pgx.CollectRows(rows, pgx.RowToStructByName[searchResultRecord])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect search results: %w", err)
+	}
+
+	results := make([]repo.SearchResult, len(records))
+	for i, rec := range records {
+		unit, err := rec.toModel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to map bank unit record: %w", err)
+		}
+		results[i] = repo.SearchResult{BankUnit: unit, Score: rec.Score}
+	}
+
+	return results, nil
+}
+
+// constraintViolation translates violations of the bank_units constraints into
+// repo errors, it returns nil for other errors.
+func constraintViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+	switch pgErr.Code {
+	case pgerrcode.UniqueViolation:
+		return repo.ErrDuplicate
+	case pgerrcode.ForeignKeyViolation:
+		return repo.ErrUnknownCountry
+	}
+	return nil
+}
+
+func swiftCodeStrings(swiftCodes []model.SwiftCode) []string {
+	codes := make([]string, len(swiftCodes))
+	for i, code := range swiftCodes {
+		codes[i] = code.String()
+	}
+	return codes
+}
+
+// lockBankUnits returns the live bank units matching cond and locks their rows until
+// the end of tx, so that their state can be recorded before they are changed.
+func (r *BankUnitRepo) lockBankUnits(ctx context.Context, tx pgx.Tx, cond string, args ...any) ([]*model.BankUnit, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT * FROM bank_units_with_country
+		WHERE id IN (
+			SELECT id FROM bank_units
+			WHERE valid_to IS NULL AND deleted_at IS NULL AND `+cond+`
+			FOR UPDATE
+		)
+		ORDER BY swift_code`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock bank units: %w", err)
+	}
+	return r.fromRowsToModels(rows)
+}
+
+func createdChanges(bankUnits []*model.BankUnit) []auditChange {
+	changes := make([]auditChange, len(bankUnits))
+	for i, bankUnit := range bankUnits {
+		changes[i] = auditChange{action: model.AuditCreate, after: bankUnit}
+	}
+	return changes
+}
+
+func deletedChanges(bankUnits []*model.BankUnit) []auditChange {
+	changes := make([]auditChange, len(bankUnits))
+	for i, bankUnit := range bankUnits {
+		changes[i] = auditChange{action: model.AuditDelete, before: bankUnit}
+	}
+	return changes
+}
+
+// bankUnitsAt returns the relation to read bank units from, the current ones for the zero
+// time and the ones as they were at asOf otherwise, in which case asOf is appended to args.
+func bankUnitsAt(asOf time.Time, args *[]any) string {
+	if asOf.IsZero() {
+		return "bank_units_with_country"
+	}
+	*args = append(*args, asOf)
+	return fmt.Sprintf("bank_units_as_of($%d)", len(*args))
+}
+
+// limitArg turns a limit where 0 means no limit into a LIMIT argument, LIMIT NULL returns all rows.
+func limitArg(limit int) *int {
+	if limit <= 0 {
+		return nil
+	}
+	return &limit
+}
+
 func (r *BankUnitRepo) fromRowsToModels(rows pgx.Rows) ([]*model.BankUnit, error) {
 	records, err := pgx.CollectRows(rows, pgx.RowToStructByName[bankUnitRecord])
 	if err != nil {
@@ -0,0 +1,31 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkarmon/swiftcodes/internal/postgres"
+	"github.com/pkarmon/swiftcodes/internal/repo/repotest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConformance(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup, err := postgres.ConfigureTestDB(ctx)
+	if err != nil {
+		t.Fatalf("could not setup test db: %v", err)
+	}
+	defer cleanup()
+
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		_ = db.DropSchema(ctx)
+		assert.NoError(t, db.SetupSchema(ctx))
+		return repotest.Repos{
+			BankUnits:  postgres.NewBankUnitRepo(db),
+			Countries:  postgres.NewCountryRepo(db),
+			Audit:      postgres.NewAuditRepo(db),
+			APIKeys:    postgres.NewAPIKeyRepo(db),
+			ImportRuns: postgres.NewImportRunRepo(db),
+		}
+	})
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pkarmon/swiftcodes/internal/model"
 	"github.com/pkarmon/swiftcodes/internal/repo"
 )
@@ -31,6 +33,10 @@ func (r *CountryRepo) BulkCreate(ctx context.Context, countries []model.Country)
 		}
 
 		_, err := tx.CopyFrom(ctx, pgx.Identifier{"countries"}, []string{"iso2", "name"}, pgx.CopyFromRows(rows))
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return repo.ErrDuplicate
+		}
 		if err != nil {
 			return fmt.Errorf("failed to copy countries: %w", err)
 		}
@@ -38,6 +44,27 @@ func (r *CountryRepo) BulkCreate(ctx context.Context, countries []model.Country)
 	})
 }
 
+func (r *CountryRepo) BulkUpsert(ctx context.Context, countries []model.Country) (int, error) {
+	codes := make([]string, len(countries))
+	names := make([]string, len(countries))
+	for i, country := range countries {
+		codes[i] = country.Code.String()
+		names[i] = country.Name
+	}
+
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO countries (iso2, name)
+		SELECT DISTINCT ON (iso2) iso2, name FROM unnest($1::text[], $2::text[]) AS c(iso2, name)
+		ON CONFLICT (iso2) DO UPDATE SET name = EXCLUDED.name
+		WHERE countries.name IS DISTINCT FROM EXCLUDED.name`,
+		codes, names)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upsert countries: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
 func (r *CountryRepo) Exists(ctx context.Context, country model.Country) (bool, error) {
 	var exists bool
 	err := r.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM countries WHERE iso2 = $1 AND name = $2)",
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -37,25 +38,28 @@ func (db *DB) Ping(ctx context.Context) error {
 	return nil
 }
 
+// SetupSchema brings the schema up to date by applying all pending migrations.
 func (db *DB) SetupSchema(ctx context.Context) error {
-	return db.InTx(ctx, func(tx pgx.Tx) error {
-		_, err := tx.Exec(ctx, setupDatabase)
-		return err
-	})
+	_, err := db.MigrateUp(ctx)
+	return err
 }
 
+// DropSchema rolls back every applied migration, leaving an empty database.
 func (db *DB) DropSchema(ctx context.Context) error {
-	return db.InTx(ctx, func(tx pgx.Tx) error {
-		_, err := tx.Exec(ctx, "DROP VIEW IF EXISTS bank_units_with_country;")
-		if err != nil {
-			return fmt.Errorf("failed to drop view: %w", err)
+	for {
+		_, err := db.MigrateDown(ctx)
+		if errors.Is(err, ErrNoMigrationsApplied) {
+			break
 		}
-		_, err = tx.Exec(ctx, "DROP TABLE IF EXISTS bank_units; DROP TABLE IF EXISTS countries;")
 		if err != nil {
-			return fmt.Errorf("failed to drop tables: %w", err)
+			return err
 		}
-		return err
-	})
+	}
+
+	if _, err := db.Exec(ctx, "DROP TABLE IF EXISTS schema_migrations"); err != nil {
+		return fmt.Errorf("failed to drop schema_migrations table: %w", err)
+	}
+	return nil
 }
 
 func (db *DB) InTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
@@ -75,35 +79,3 @@ func (db *DB) InTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
 
 	return nil
 }
-
-const setupDatabase = `
-CREATE TABLE IF NOT EXISTS countries (
-	iso2 CHAR(2) PRIMARY KEY,
-	name VARCHAR(255) NOT NULL
-);
-
-CREATE TABLE IF NOT EXISTS bank_units (
-    id SERIAL PRIMARY KEY,
-    country_iso2 CHAR(2) NOT NULL REFERENCES countries(iso2),
-    swift_code CHAR(11) NOT NULL UNIQUE,
-    name VARCHAR(255) NOT NULL,
-	address TEXT NOT NULL,
-    is_headquarter BOOLEAN NOT NULL
-);
-
-CREATE INDEX IF NOT EXISTS idx_bank_units_country_iso2 ON bank_units (country_iso2);
-CREATE INDEX IF NOT EXISTS idx_bank_units_swift_code ON bank_units (swift_code);
-CREATE INDEX IF NOT EXISTS idx_bank_units_base_code ON bank_units (LEFT(swift_code, 8));
-
-CREATE OR REPLACE VIEW bank_units_with_country AS
-SELECT
-    bu.id,
-    bu.country_iso2,
-    bu.swift_code,
-    bu.name as bank_name,
-    bu.address,
-    bu.is_headquarter,
-    c.name as country_name
-FROM bank_units bu
-JOIN countries c ON bu.country_iso2 = c.iso2;
-`
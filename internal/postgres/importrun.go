@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+)
+
+type ImportRunRepo struct {
+	db DB
+}
+
+type importRunRecord struct {
+	ID          int       `db:"id"`
+	Source      string    `db:"source"`
+	FileHash    string    `db:"file_hash"`
+	RowsRead    int       `db:"rows_read"`
+	RowsChanged int       `db:"rows_changed"`
+	ImportedAt  time.Time `db:"imported_at"`
+}
+
+func NewImportRunRepo(db DB) *ImportRunRepo {
+	return &ImportRunRepo{db: db}
+}
+
+func (r *ImportRunRepo) Create(ctx context.Context, run model.ImportRun) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO import_runs (source, file_hash, rows_read, rows_changed)
+		VALUES ($1, $2, $3, $4)`,
+		run.Source, run.FileHash, run.RowsRead, run.RowsChanged)
+	if err != nil {
+		return fmt.Errorf("failed to create import run: %w", err)
+	}
+	return nil
+}
+
+func (r *ImportRunRepo) GetLatest(ctx context.Context, source string) (model.ImportRun, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT * FROM import_runs
+		WHERE source = $1
+		ORDER BY imported_at DESC, id DESC
+		LIMIT 1`,
+		source)
+	if err != nil {
+		return model.ImportRun{}, fmt.Errorf("failed to get latest import run: %w", err)
+	}
+
+	rec, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[importRunRecord])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ImportRun{}, repo.ErrNotFound
+	}
+	if err != nil {
+		return model.ImportRun{}, fmt.Errorf("failed to collect import run: %w", err)
+	}
+
+	return model.ImportRun{
+		Source:      rec.Source,
+		FileHash:    rec.FileHash,
+		RowsRead:    rec.RowsRead,
+		RowsChanged: rec.RowsChanged,
+		ImportedAt:  rec.ImportedAt,
+	}, nil
+}
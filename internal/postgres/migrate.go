@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID guards against two processes migrating the same database at once.
+const migrationLockID = 7_240_311_195
+
+var ErrNoMigrationsApplied = errors.New("no migrations applied")
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// loadMigrations reads the embedded migrations. Every migration consists of two files,
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+func loadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		filename := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(filename, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name %q", filename)
+		}
+		versionStr, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", filename)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", filename, err)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join("migrations", filename))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", filename, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d is missing its up or down file", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// MigrateUp applies all pending migrations in order, each in its own transaction,
+// and returns the number of applied migrations.
+func (db *DB) MigrateUp(ctx context.Context) (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if err := db.ensureMigrationsTable(ctx); err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		err := db.InTx(ctx, func(tx pgx.Tx) error {
+			if err := lockMigrations(ctx, tx); err != nil {
+				return err
+			}
+
+			var done bool
+			err := tx.QueryRow(ctx,
+				"SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)", m.Version).Scan(&done)
+			if err != nil {
+				return fmt.Errorf("failed to check migration %d: %w", m.Version, err)
+			}
+			if done {
+				return nil
+			}
+
+			if _, err := tx.Exec(ctx, m.Up); err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			_, err = tx.Exec(ctx,
+				"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name)
+			if err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+			}
+			applied++
+			return nil
+		})
+		if err != nil {
+			return applied, err
+		}
+	}
+
+	return applied, nil
+}
+
+// MigrateDown rolls back the most recently applied migration and returns it.
+func (db *DB) MigrateDown(ctx context.Context) (Migration, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return Migration{}, err
+	}
+	if err := db.ensureMigrationsTable(ctx); err != nil {
+		return Migration{}, err
+	}
+
+	var rolledBack Migration
+	err = db.InTx(ctx, func(tx pgx.Tx) error {
+		if err := lockMigrations(ctx, tx); err != nil {
+			return err
+		}
+
+		var version int
+		err := tx.QueryRow(ctx, "SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1").Scan(&version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNoMigrationsApplied
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get current migration: %w", err)
+		}
+
+		idx := sort.Search(len(migrations), func(i int) bool { return migrations[i].Version >= version })
+		if idx == len(migrations) || migrations[idx].Version != version {
+			return fmt.Errorf("applied migration %d is unknown to this binary", version)
+		}
+		rolledBack = migrations[idx]
+
+		if _, err := tx.Exec(ctx, rolledBack.Down); err != nil {
+			return fmt.Errorf("failed to roll back migration %d_%s: %w", rolledBack.Version, rolledBack.Name, err)
+		}
+		if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", version); err != nil {
+			return fmt.Errorf("failed to unrecord migration %d: %w", version, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return Migration{}, err
+	}
+
+	return rolledBack, nil
+}
+
+// MigrationStatus lists all known migrations together with the time they were applied,
+// AppliedAt is nil for pending migrations.
+func (db *DB) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.ensureMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	appliedAt := map[int]time.Time{}
+	var (
+		version int
+		applied time.Time
+	)
+	_, err = pgx.ForEachRow(rows, []any{&version, &applied}, func() error {
+		appliedAt[version] = applied
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect applied migrations: %w", err)
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		statuses[i] = MigrationStatus{Version: m.Version, Name: m.Name}
+		if at, ok := appliedAt[m.Version]; ok {
+			statuses[i].AppliedAt = &at
+		}
+	}
+
+	return statuses, nil
+}
+
+func (db *DB) ensureMigrationsTable(ctx context.Context) error {
+	_, err := db.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func lockMigrations(ctx context.Context, tx pgx.Tx) error {
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,3 @@
+DROP VIEW IF EXISTS bank_units_with_country;
+DROP TABLE IF EXISTS bank_units;
+DROP TABLE IF EXISTS countries;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS countries (
+	iso2 CHAR(2) PRIMARY KEY,
+	name VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS bank_units (
+    id SERIAL PRIMARY KEY,
+    country_iso2 CHAR(2) NOT NULL REFERENCES countries(iso2),
+    swift_code CHAR(11) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+	address TEXT NOT NULL,
+    is_headquarter BOOLEAN NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_bank_units_country_iso2 ON bank_units (country_iso2);
+CREATE INDEX IF NOT EXISTS idx_bank_units_swift_code ON bank_units (swift_code);
+CREATE INDEX IF NOT EXISTS idx_bank_units_base_code ON bank_units (LEFT(swift_code, 8));
+
+CREATE OR REPLACE VIEW bank_units_with_country AS
+SELECT
+    bu.id,
+    bu.country_iso2,
+    bu.swift_code,
+    bu.name as bank_name,
+    bu.address,
+    bu.is_headquarter,
+    c.name as country_name
+FROM bank_units bu
+JOIN countries c ON bu.country_iso2 = c.iso2;
@@ -0,0 +1,17 @@
+DROP VIEW IF EXISTS bank_units_with_country;
+CREATE VIEW bank_units_with_country AS
+SELECT
+    bu.id,
+    bu.country_iso2,
+    bu.swift_code,
+    bu.name as bank_name,
+    bu.address,
+    bu.is_headquarter,
+    c.name as country_name
+FROM bank_units bu
+JOIN countries c ON bu.country_iso2 = c.iso2;
+
+ALTER TABLE bank_units
+    DROP COLUMN IF EXISTS code_type,
+    DROP COLUMN IF EXISTS town_name,
+    DROP COLUMN IF EXISTS time_zone;
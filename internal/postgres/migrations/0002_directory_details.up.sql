@@ -0,0 +1,20 @@
+ALTER TABLE bank_units
+    ADD COLUMN IF NOT EXISTS code_type TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS town_name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64) NOT NULL DEFAULT '';
+
+DROP VIEW IF EXISTS bank_units_with_country;
+CREATE VIEW bank_units_with_country AS
+SELECT
+    bu.id,
+    bu.country_iso2,
+    bu.swift_code,
+    bu.name as bank_name,
+    bu.address,
+    bu.is_headquarter,
+    bu.code_type,
+    bu.town_name,
+    bu.time_zone,
+    c.name as country_name
+FROM bank_units bu
+JOIN countries c ON bu.country_iso2 = c.iso2;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS import_runs (
+    id SERIAL PRIMARY KEY,
+    source TEXT NOT NULL,
+    file_hash CHAR(64) NOT NULL,
+    rows_read INTEGER NOT NULL,
+    rows_changed INTEGER NOT NULL,
+    imported_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_runs_source ON import_runs (source, imported_at DESC);
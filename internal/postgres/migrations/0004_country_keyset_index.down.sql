@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_bank_units_country_iso2 ON bank_units (country_iso2);
+DROP INDEX IF EXISTS idx_bank_units_country_swift_code;
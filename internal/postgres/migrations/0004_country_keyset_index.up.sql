@@ -0,0 +1,3 @@
+-- Supports keyset pagination over a country's bank units ordered by swift code.
+CREATE INDEX IF NOT EXISTS idx_bank_units_country_swift_code ON bank_units (country_iso2, swift_code);
+DROP INDEX IF EXISTS idx_bank_units_country_iso2;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_bank_units_address_trgm;
+DROP INDEX IF EXISTS idx_bank_units_name_trgm;
+DROP INDEX IF EXISTS idx_bank_units_search_document;
+
+ALTER TABLE bank_units DROP COLUMN IF EXISTS search_document;
+
+DROP FUNCTION IF EXISTS immutable_unaccent(text);
+DROP EXTENSION IF EXISTS unaccent;
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,18 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- unaccent() is only STABLE, so it cannot be used in indexes or generated columns directly.
+CREATE OR REPLACE FUNCTION immutable_unaccent(text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+    AS $$ SELECT public.unaccent('public.unaccent'::regdictionary, $1) $$;
+
+ALTER TABLE bank_units
+    ADD COLUMN IF NOT EXISTS search_document tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', immutable_unaccent(name)), 'A') ||
+        setweight(to_tsvector('simple', immutable_unaccent(town_name)), 'B') ||
+        setweight(to_tsvector('simple', immutable_unaccent(address)), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_bank_units_search_document ON bank_units USING GIN (search_document);
+CREATE INDEX IF NOT EXISTS idx_bank_units_name_trgm ON bank_units USING GIN (immutable_unaccent(lower(name)) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_bank_units_address_trgm ON bank_units USING GIN (immutable_unaccent(lower(address)) gin_trgm_ops);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_bank_units_swift_code_pattern;
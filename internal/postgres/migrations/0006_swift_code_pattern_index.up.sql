@@ -0,0 +1,2 @@
+-- Lets LIKE 'DEUT%' prefix patterns use an index regardless of the database collation.
+CREATE INDEX IF NOT EXISTS idx_bank_units_swift_code_pattern ON bank_units (swift_code bpchar_pattern_ops);
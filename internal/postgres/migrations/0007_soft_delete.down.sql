@@ -0,0 +1,23 @@
+DROP VIEW IF EXISTS bank_units_with_country;
+CREATE VIEW bank_units_with_country AS
+SELECT
+    bu.id,
+    bu.country_iso2,
+    bu.swift_code,
+    bu.name as bank_name,
+    bu.address,
+    bu.is_headquarter,
+    bu.code_type,
+    bu.town_name,
+    bu.time_zone,
+    c.name as country_name
+FROM bank_units bu
+JOIN countries c ON bu.country_iso2 = c.iso2;
+
+DELETE FROM bank_units WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_bank_units_deleted_at;
+DROP INDEX IF EXISTS bank_units_swift_code_live_key;
+ALTER TABLE bank_units ADD CONSTRAINT bank_units_swift_code_key UNIQUE (swift_code);
+
+ALTER TABLE bank_units DROP COLUMN IF EXISTS deleted_at;
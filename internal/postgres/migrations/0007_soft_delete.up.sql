@@ -0,0 +1,23 @@
+ALTER TABLE bank_units ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Deleted bank units are kept, so a swift code only has to be unique among the live ones.
+ALTER TABLE bank_units DROP CONSTRAINT IF EXISTS bank_units_swift_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS bank_units_swift_code_live_key ON bank_units (swift_code) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_bank_units_deleted_at ON bank_units (deleted_at) WHERE deleted_at IS NOT NULL;
+
+DROP VIEW IF EXISTS bank_units_with_country;
+CREATE VIEW bank_units_with_country AS
+SELECT
+    bu.id,
+    bu.country_iso2,
+    bu.swift_code,
+    bu.name as bank_name,
+    bu.address,
+    bu.is_headquarter,
+    bu.code_type,
+    bu.town_name,
+    bu.time_zone,
+    bu.deleted_at,
+    c.name as country_name
+FROM bank_units bu
+JOIN countries c ON bu.country_iso2 = c.iso2;
@@ -0,0 +1,28 @@
+DROP FUNCTION IF EXISTS bank_units_as_of(TIMESTAMPTZ);
+
+DROP VIEW IF EXISTS bank_units_with_country;
+CREATE VIEW bank_units_with_country AS
+SELECT
+    bu.id,
+    bu.country_iso2,
+    bu.swift_code,
+    bu.name as bank_name,
+    bu.address,
+    bu.is_headquarter,
+    bu.code_type,
+    bu.town_name,
+    bu.time_zone,
+    bu.deleted_at,
+    c.name as country_name
+FROM bank_units bu
+JOIN countries c ON bu.country_iso2 = c.iso2;
+
+DELETE FROM bank_units WHERE valid_to IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_bank_units_swift_code_valid_from;
+DROP INDEX IF EXISTS bank_units_swift_code_live_key;
+CREATE UNIQUE INDEX IF NOT EXISTS bank_units_swift_code_live_key ON bank_units (swift_code) WHERE deleted_at IS NULL;
+
+ALTER TABLE bank_units DROP CONSTRAINT IF EXISTS bank_units_validity_check;
+ALTER TABLE bank_units DROP COLUMN IF EXISTS valid_to;
+ALTER TABLE bank_units DROP COLUMN IF EXISTS valid_from;
@@ -0,0 +1,57 @@
+-- Every change closes the current version of a bank unit (valid_to) and inserts a new one,
+-- so the directory can be queried as it was at any point in time.
+ALTER TABLE bank_units ADD COLUMN IF NOT EXISTS valid_from TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE bank_units ADD COLUMN IF NOT EXISTS valid_to TIMESTAMPTZ;
+-- units deleted before versioning existed were never visible as of a later time
+UPDATE bank_units SET valid_from = deleted_at WHERE deleted_at < valid_from;
+ALTER TABLE bank_units ADD CONSTRAINT bank_units_validity_check CHECK (valid_to IS NULL OR valid_to >= valid_from);
+
+DROP INDEX IF EXISTS bank_units_swift_code_live_key;
+CREATE UNIQUE INDEX IF NOT EXISTS bank_units_swift_code_live_key ON bank_units (swift_code)
+    WHERE deleted_at IS NULL AND valid_to IS NULL;
+CREATE INDEX IF NOT EXISTS idx_bank_units_swift_code_valid_from ON bank_units (swift_code, valid_from);
+
+-- the view only shows current versions, older ones are read through bank_units_as_of
+DROP VIEW IF EXISTS bank_units_with_country;
+CREATE VIEW bank_units_with_country AS
+SELECT
+    bu.id,
+    bu.country_iso2,
+    bu.swift_code,
+    bu.name as bank_name,
+    bu.address,
+    bu.is_headquarter,
+    bu.code_type,
+    bu.town_name,
+    bu.time_zone,
+    bu.deleted_at,
+    bu.valid_from,
+    bu.valid_to,
+    c.name as country_name
+FROM bank_units bu
+JOIN countries c ON bu.country_iso2 = c.iso2
+WHERE bu.valid_to IS NULL;
+
+-- bank_units_as_of returns the bank units as they were at ts, in the shape of bank_units_with_country.
+-- Units deleted after ts were not deleted yet, so deleted_at is always NULL.
+CREATE OR REPLACE FUNCTION bank_units_as_of(ts TIMESTAMPTZ) RETURNS SETOF bank_units_with_country AS $$
+    SELECT
+        bu.id,
+        bu.country_iso2,
+        bu.swift_code,
+        bu.name,
+        bu.address,
+        bu.is_headquarter,
+        bu.code_type,
+        bu.town_name,
+        bu.time_zone,
+        NULL::TIMESTAMPTZ,
+        bu.valid_from,
+        bu.valid_to,
+        c.name
+    FROM bank_units bu
+    JOIN countries c ON bu.country_iso2 = c.iso2
+    WHERE bu.valid_from <= ts
+        AND (bu.valid_to IS NULL OR bu.valid_to > ts)
+        AND (bu.deleted_at IS NULL OR bu.deleted_at > ts)
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    prefix VARCHAR(16) NOT NULL,
+    secret_hash CHAR(64) NOT NULL,
+    owner TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    rotated_from BIGINT REFERENCES api_keys (id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_secret_hash ON api_keys (secret_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_owner ON api_keys (owner, id);
@@ -0,0 +1,108 @@
+// Package ratelimit limits how often clients may call the API with token buckets.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets that refilled completely are dropped, a
+// full bucket behaves the same as a missing one.
+const sweepInterval = time.Minute
+
+// Limiter keeps a token bucket per client. Each bucket holds up to burst tokens and
+// refills at a steady rate, every request takes one token.
+type Limiter struct {
+	perSecond float64
+	burst     int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Decision is the outcome of a request for a token.
+type Decision struct {
+	Allowed bool
+	// Limit is the size of the bucket and Remaining the tokens left in it.
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token is available, zero when Allowed.
+	RetryAfter time.Duration
+}
+
+// NewLimiter returns a limiter granting perMinute requests a minute on average, with
+// bursts of up to burst requests. It returns nil, which allows everything, when
+// perMinute is not positive. A burst below one is raised to one.
+func NewLimiter(perMinute, burst int) *Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &Limiter{
+		perSecond: float64(perMinute) / 60,
+		burst:     max(burst, 1),
+		buckets:   map[string]*bucket{},
+	}
+}
+
+// Allow takes a token from the bucket of the client at the given time.
+func (l *Limiter) Allow(client string, now time.Time) Decision {
+	if l == nil {
+		return Decision{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[client] = b
+	}
+	b.refill(now, l.perSecond, l.burst)
+
+	d := Decision{Limit: l.burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.Allowed = true
+	} else {
+		d.RetryAfter = l.duration(1 - b.tokens)
+	}
+	d.Remaining = int(b.tokens)
+	d.Reset = l.duration(float64(l.burst) - b.tokens)
+	return d
+}
+
+func (b *bucket) refill(now time.Time, perSecond float64, burst int) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+elapsed.Seconds()*perSecond)
+		b.last = now
+	}
+}
+
+// duration returns how long it takes to refill the given number of tokens.
+func (l *Limiter) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / l.perSecond * float64(time.Second))
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for client, b := range l.buckets {
+		b.refill(now, l.perSecond, l.burst)
+		if b.tokens >= float64(l.burst) {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastSweep = now
+}
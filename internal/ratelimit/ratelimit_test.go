@@ -0,0 +1,63 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("burst then steady rate", func(t *testing.T) {
+		l := ratelimit.NewLimiter(60, 3)
+
+		for i := range 3 {
+			d := l.Allow("a", start)
+			assert.True(t, d.Allowed)
+			assert.Equal(t, 3, d.Limit)
+			assert.Equal(t, 2-i, d.Remaining)
+		}
+
+		d := l.Allow("a", start)
+		assert.False(t, d.Allowed)
+		assert.Equal(t, 0, d.Remaining)
+		assert.Equal(t, time.Second, d.RetryAfter)
+		assert.Equal(t, 3*time.Second, d.Reset)
+
+		d = l.Allow("a", start.Add(500*time.Millisecond))
+		assert.False(t, d.Allowed)
+		assert.Equal(t, 500*time.Millisecond, d.RetryAfter)
+
+		d = l.Allow("a", start.Add(time.Second))
+		assert.True(t, d.Allowed)
+		assert.Equal(t, time.Duration(0), d.RetryAfter)
+	})
+
+	t.Run("clients have their own buckets", func(t *testing.T) {
+		l := ratelimit.NewLimiter(60, 1)
+
+		assert.True(t, l.Allow("a", start).Allowed)
+		assert.False(t, l.Allow("a", start).Allowed)
+		assert.True(t, l.Allow("b", start).Allowed)
+	})
+
+	t.Run("bucket does not grow past burst", func(t *testing.T) {
+		l := ratelimit.NewLimiter(60, 2)
+
+		assert.True(t, l.Allow("a", start).Allowed)
+		d := l.Allow("a", start.Add(time.Hour))
+		assert.True(t, d.Allowed)
+		assert.Equal(t, 1, d.Remaining)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		l := ratelimit.NewLimiter(0, 10)
+		assert.Nil(t, l)
+		for range 100 {
+			assert.True(t, l.Allow("a", start).Allowed)
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+)
+
+// APIKey stores issued API keys by the hash of their secret.
+type APIKey interface {
+	// Create stores the key and sets its ID and CreatedAt.
+	Create(ctx context.Context, key *model.APIKey, secretHash string) error
+	// Use returns the active key with the hash and records it as used, ErrNotFound
+	// is returned for unknown, revoked and expired keys.
+	Use(ctx context.Context, secretHash string) (*model.APIKey, error)
+	List(ctx context.Context, includeRevoked bool) ([]*model.APIKey, error)
+	// Rotate revokes the active key with the id and stores its replacement, which
+	// keeps the owner, scopes and expiry. ErrNotFound is returned when there is no
+	// such active key.
+	Rotate(ctx context.Context, id int64, prefix, secretHash string) (*model.APIKey, error)
+	// Revoke returns ErrNotFound for unknown and already revoked keys.
+	Revoke(ctx context.Context, id int64) error
+}
@@ -0,0 +1,27 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+)
+
+// Audit reads the audit events. Events are written by the BankUnit repository
+// in the same transaction as the change they describe.
+type Audit interface {
+	// List returns the events matching the filter, the most recent first.
+	List(ctx context.Context, filter AuditFilter) ([]*model.AuditEvent, error)
+}
+
+// AuditFilter narrows the listed audit events, zero values match everything.
+type AuditFilter struct {
+	SwiftCode string
+	Actor     string
+	// From and To limit the time the events occurred at, From is inclusive and To exclusive.
+	From time.Time
+	To   time.Time
+	// BeforeID lists only events older than the one with this id, it is used for paging.
+	BeforeID int64
+	Limit    int
+}
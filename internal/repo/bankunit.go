@@ -2,6 +2,8 @@ package repo
 
 import (
 	"context"
+	"iter"
+	"time"
 
 	"github.com/pkarmon/swiftcodes/internal/model"
 )
@@ -9,10 +11,103 @@ import (
 type BankUnit interface {
 	Create(ctx context.Context, bank *model.BankUnit) error
 	BulkCreate(ctx context.Context, banks []*model.BankUnit) error
-	GetBySwiftCode(ctx context.Context, swiftCode model.SwiftCode) (*model.BankUnit, error)
-	GetAllByCountry(ctx context.Context, countryISO2 model.CountryISO2) ([]*model.BankUnit, error)
+	// BulkCreateFrom inserts bank units as they are produced by the iterator, without holding
+	// them all in memory. Nothing is inserted when the iterator yields an error.
+	// It returns the number of inserted bank units.
+	BulkCreateFrom(ctx context.Context, banks iter.Seq2[*model.BankUnit, error]) (int, error)
+	// BulkUpsert inserts new bank units and updates existing ones by swift code,
+	// returning the number of rows that actually changed. Nothing is changed when
+	// the iterator yields an error.
+	BulkUpsert(ctx context.Context, banks iter.Seq2[*model.BankUnit, error]) (int, error)
+	// GetBySwiftCode returns the live bank unit with the swift code. With opts.IncludeDeleted
+	// the most recently deleted one is returned when there is no live bank unit.
+	GetBySwiftCode(ctx context.Context, swiftCode model.SwiftCode, opts ReadOptions) (*model.BankUnit, error)
+	// GetBySwiftCodes returns the bank units with the given swift codes in any order,
+	// codes that do not exist are skipped.
+	GetBySwiftCodes(ctx context.Context, swiftCodes []model.SwiftCode) ([]*model.BankUnit, error)
+	GetAllByCountry(ctx context.Context, countryISO2 model.CountryISO2, opts ListOptions) ([]*model.BankUnit, error)
+	// FindByPattern returns the bank units whose swift codes match the pattern.
+	FindByPattern(ctx context.Context, pattern model.SwiftCodePattern, opts ListOptions) ([]*model.BankUnit, error)
+	// Update replaces the live bank unit with the same swift code, it returns ErrNotFound
+	// when there is no such bank unit.
+	Update(ctx context.Context, bank *model.BankUnit) error
+	// DeleteAll permanently removes all bank units, including the deleted ones.
 	DeleteAll(ctx context.Context) error
-	Delete(ctx context.Context, swiftCode model.SwiftCode) error
-	GetAll(ctx context.Context) ([]*model.BankUnit, error)
-	GetBranches(ctx context.Context, swiftCode model.SwiftCode) ([]*model.BankUnit, error)
+	// Delete marks the live bank unit as deleted, it returns ErrNotFound when there is no such
+	// bank unit and ErrHasBranches for headquarters with branches, unless opts.Cascade is set.
+	Delete(ctx context.Context, swiftCode model.SwiftCode, opts DeleteOptions) error
+	// Restore brings back the most recently deleted bank unit with the swift code. It returns
+	// ErrNotFound when there is none and ErrDuplicate when the swift code was created again.
+	Restore(ctx context.Context, swiftCode model.SwiftCode) (*model.BankUnit, error)
+	// GetDeleted lists deleted bank units, the most recently deleted first.
+	GetDeleted(ctx context.Context, opts DeletedListOptions) ([]*model.BankUnit, error)
+	GetAll(ctx context.Context, opts ReadOptions) ([]*model.BankUnit, error)
+	GetBranches(ctx context.Context, swiftCode model.SwiftCode, opts ReadOptions) ([]*model.BankUnit, error)
+	// GetBranchesOf returns the branches of all given headquarters in any order.
+	GetBranchesOf(ctx context.Context, headquarters []model.SwiftCode) ([]*model.BankUnit, error)
+	// ApplyChangeset applies all changes in a single transaction.
+	ApplyChangeset(ctx context.Context, changes Changeset) error
+	// Search finds bank units whose name, address or town match the query text,
+	// ordered by descending relevance.
+	Search(ctx context.Context, query SearchQuery) ([]SearchResult, error)
+}
+
+// SearchQuery describes a free text search over bank names, addresses and towns.
+type SearchQuery struct {
+	Text string
+	// CountryISO2 limits results to a single country, the zero value matches all countries.
+	CountryISO2      model.CountryISO2
+	HeadquartersOnly bool
+	// Limit caps the number of results, 0 means no limit.
+	Limit int
+}
+
+type SearchResult struct {
+	BankUnit *model.BankUnit
+	// Score is the relevance of the match, higher is better. Scores are only
+	// comparable between results of the same search.
+	Score float64
+}
+
+// ReadOptions control which bank units are visible to read methods. Deleted bank units
+// are skipped by default, methods without ReadOptions only return live bank units.
+type ReadOptions struct {
+	IncludeDeleted bool
+	// AsOf reads the bank units as they were at the given time, the zero value reads
+	// the current ones.
+	AsOf time.Time
+}
+
+type DeletedListOptions struct {
+	// Since skips bank units deleted before it, the zero value lists all of them.
+	Since time.Time
+	// Limit caps the number of returned bank units, 0 means no limit.
+	Limit int
+}
+
+// ListOptions narrows down the bank units returned by listing methods.
+// Listed bank units are ordered by swift code.
+type ListOptions struct {
+	// ExcludeTestBICs skips test and training BICs (see model.SwiftCode.IsTestBIC).
+	ExcludeTestBICs bool
+	// After returns only bank units with swift codes greater than After, it is used
+	// to continue from the last bank unit of the previous page.
+	After string
+	// Limit caps the number of returned bank units, 0 means no limit.
+	Limit int
+	// AsOf lists the bank units as they were at the given time, see ReadOptions.AsOf.
+	AsOf time.Time
+}
+
+type DeleteOptions struct {
+	// Cascade deletes the branches of deleted headquarters as well.
+	Cascade bool
+}
+
+// Changeset groups bank unit changes that are applied together.
+type Changeset struct {
+	Create []*model.BankUnit
+	// Update replaces the bank units with matching swift codes.
+	Update []*model.BankUnit
+	Delete []model.SwiftCode
 }
@@ -8,6 +8,9 @@ import (
 
 type Country interface {
 	BulkCreate(ctx context.Context, countries []model.Country) error
+	// BulkUpsert inserts new countries and renames existing ones by ISO2 code,
+	// returning the number of rows that actually changed.
+	BulkUpsert(ctx context.Context, countries []model.Country) (int, error)
 	GetByCode(ctx context.Context, code model.CountryISO2) (model.Country, error)
 	Exists(ctx context.Context, country model.Country) (bool, error)
 	GetAll(ctx context.Context) ([]model.Country, error)
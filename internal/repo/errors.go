@@ -5,4 +5,8 @@ import "errors"
 var (
 	ErrNotFound  = errors.New("not found")
 	ErrDuplicate = errors.New("duplicate")
+	// ErrHasBranches is returned when deleting headquarters that still have branches.
+	ErrHasBranches = errors.New("headquarters has branches")
+	// ErrUnknownCountry is returned when a bank unit refers to a country that does not exist.
+	ErrUnknownCountry = errors.New("unknown country")
 )
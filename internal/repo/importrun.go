@@ -0,0 +1,13 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+)
+
+type ImportRun interface {
+	Create(ctx context.Context, run model.ImportRun) error
+	// GetLatest returns the most recent run for the source or ErrNotFound.
+	GetLatest(ctx context.Context, source string) (model.ImportRun, error)
+}
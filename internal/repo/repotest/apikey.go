@@ -0,0 +1,85 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/auth"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAPIKeys(t *testing.T, r Repos) {
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+	expiredAt := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+
+	ops := &model.APIKey{Prefix: "sc_opsopsop", Owner: "ops", Scopes: []string{"admin"}}
+	ci := &model.APIKey{Prefix: "sc_cicicici", Owner: "ci", Scopes: []string{"reader", "editor"}, ExpiresAt: &expiresAt}
+	expired := &model.APIKey{Prefix: "sc_expired", Owner: "ci", Scopes: []string{"reader"}, ExpiresAt: &expiredAt}
+	assert.NoError(t, r.APIKeys.Create(ctx, ops, auth.HashKey("ops")))
+	assert.NoError(t, r.APIKeys.Create(ctx, ci, auth.HashKey("ci")))
+	assert.NoError(t, r.APIKeys.Create(ctx, expired, auth.HashKey("expired")))
+	assert.NotZero(t, ops.ID)
+	assert.False(t, ops.CreatedAt.IsZero())
+	assert.ErrorIs(t, r.APIKeys.Create(ctx, &model.APIKey{Prefix: "sc_other", Owner: "x", Scopes: []string{"reader"}}, auth.HashKey("ops")),
+		repo.ErrDuplicate)
+
+	used, err := r.APIKeys.Use(ctx, auth.HashKey("ci"))
+	assert.NoError(t, err)
+	assert.Equal(t, ci.ID, used.ID)
+	assert.Equal(t, "ci", used.Owner)
+	assert.Equal(t, []string{"reader", "editor"}, used.Scopes)
+	assert.True(t, expiresAt.Equal(*used.ExpiresAt))
+	assert.NotNil(t, used.LastUsedAt)
+	_, err = r.APIKeys.Use(ctx, auth.HashKey("unknown"))
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+	_, err = r.APIKeys.Use(ctx, auth.HashKey("expired"))
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+
+	rotated, err := r.APIKeys.Rotate(ctx, ci.ID, "sc_rotated", auth.HashKey("rotated"))
+	assert.NoError(t, err)
+	assert.Equal(t, "sc_rotated", rotated.Prefix)
+	assert.Equal(t, "ci", rotated.Owner)
+	assert.Equal(t, []string{"reader", "editor"}, rotated.Scopes)
+	assert.True(t, expiresAt.Equal(*rotated.ExpiresAt))
+	assert.Equal(t, ci.ID, *rotated.RotatedFrom)
+	assert.Nil(t, rotated.RevokedAt)
+	_, err = r.APIKeys.Use(ctx, auth.HashKey("ci"))
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+	_, err = r.APIKeys.Use(ctx, auth.HashKey("rotated"))
+	assert.NoError(t, err)
+	_, err = r.APIKeys.Rotate(ctx, rotated.ID, "sc_clash", auth.HashKey("ops"))
+	assert.ErrorIs(t, err, repo.ErrDuplicate)
+	_, err = r.APIKeys.Use(ctx, auth.HashKey("rotated"))
+	assert.NoError(t, err, "a failed rotation does not revoke the key")
+	_, err = r.APIKeys.Rotate(ctx, ci.ID, "sc_again", auth.HashKey("again"))
+	assert.ErrorIs(t, err, repo.ErrNotFound, "a revoked key cannot be rotated")
+	_, err = r.APIKeys.Rotate(ctx, expired.ID, "sc_again", auth.HashKey("again"))
+	assert.ErrorIs(t, err, repo.ErrNotFound, "an expired key cannot be rotated")
+
+	assert.NoError(t, r.APIKeys.Revoke(ctx, ops.ID))
+	assert.ErrorIs(t, r.APIKeys.Revoke(ctx, ops.ID), repo.ErrNotFound)
+	assert.ErrorIs(t, r.APIKeys.Revoke(ctx, rotated.ID+100), repo.ErrNotFound)
+	_, err = r.APIKeys.Use(ctx, auth.HashKey("ops"))
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+
+	keys, err := r.APIKeys.List(ctx, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{expired.ID, rotated.ID}, keyIDs(keys), "expired keys are listed until revoked")
+	keys, err = r.APIKeys.List(ctx, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{ci.ID, expired.ID, rotated.ID, ops.ID}, keyIDs(keys), "ordered by owner and id")
+	assert.NotNil(t, keys[0].RevokedAt)
+	assert.NotNil(t, keys[0].LastUsedAt)
+}
+
+func keyIDs(keys []*model.APIKey) []int64 {
+	ids := make([]int64, len(keys))
+	for i, key := range keys {
+		ids[i] = key.ID
+	}
+	return ids
+}
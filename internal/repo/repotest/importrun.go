@@ -0,0 +1,33 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+func testImportRuns(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	_, err := r.ImportRuns.GetLatest(ctx, "banks.csv")
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+
+	assert.NoError(t, r.ImportRuns.Create(ctx, model.ImportRun{Source: "banks.csv", FileHash: "first", RowsRead: 3, RowsChanged: 3}))
+	assert.NoError(t, r.ImportRuns.Create(ctx, model.ImportRun{Source: "banks.csv", FileHash: "second", RowsRead: 4, RowsChanged: 1}))
+	assert.NoError(t, r.ImportRuns.Create(ctx, model.ImportRun{Source: "countries.csv", FileHash: "other", RowsRead: 2}))
+
+	latest, err := r.ImportRuns.GetLatest(ctx, "banks.csv")
+	assert.NoError(t, err)
+	assert.Equal(t, "banks.csv", latest.Source)
+	assert.Equal(t, "second", latest.FileHash)
+	assert.Equal(t, 4, latest.RowsRead)
+	assert.Equal(t, 1, latest.RowsChanged)
+	assert.False(t, latest.ImportedAt.IsZero())
+
+	latest, err = r.ImportRuns.GetLatest(ctx, "countries.csv")
+	assert.NoError(t, err)
+	assert.Equal(t, "other", latest.FileHash)
+}
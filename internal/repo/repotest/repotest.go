@@ -0,0 +1,623 @@
+// Package repotest holds the conformance tests every implementation of the
+// repositories must pass, so that the implementations can replace each other.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/pkarmon/swiftcodes/internal/audit"
+	"github.com/pkarmon/swiftcodes/internal/model"
+	"github.com/pkarmon/swiftcodes/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+// Repos are the repositories under test, they must share their storage.
+type Repos struct {
+	BankUnits  repo.BankUnit
+	Countries  repo.Country
+	Audit      repo.Audit
+	APIKeys    repo.APIKey
+	ImportRuns repo.ImportRun
+}
+
+// Run runs the conformance tests. setup is called at the start of every test and
+// must return repositories without any data.
+func Run(t *testing.T, setup func(t *testing.T) Repos) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, r Repos)
+	}{
+		{"countries", testCountries},
+		{"create and get", testCreateAndGet},
+		{"branches", testBranches},
+		{"update", testUpdate},
+		{"delete", testDelete},
+		{"restore", testRestore},
+		{"list", testList},
+		{"bulk", testBulk},
+		{"changeset", testChangeset},
+		{"point in time", testPointInTime},
+		{"search", testSearch},
+		{"audit", testAudit},
+		{"api keys", testAPIKeys},
+		{"import runs", testImportRuns},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, setup(t))
+		})
+	}
+}
+
+const (
+	hqCode       = "BPKOPLPWXXX"
+	branchCode   = "BPKOPLPWCSD"
+	gdyniaCode   = "BPKOPLPWGDG"
+	bulgarianHQ  = "BEFNBGS1XXX"
+	unknownCode  = "DEUTDEFFXXX"
+	hqAddress    = "UL. PULAWSKA 15  WARSZAWA, MAZOWIECKIE, 02-515"
+	gdyniaStreet = "SWIETOJANSKA 17  GDYNIA, POMORSKIE, 71-368"
+)
+
+// seed stores Poland, Bulgaria and Germany with a Polish headquarters, its two
+// branches and a Bulgarian headquarters.
+func seed(t *testing.T, r Repos) {
+	t.Helper()
+	ctx := context.Background()
+
+	assert.NoError(t, r.Countries.BulkCreate(ctx, []model.Country{
+		must(model.NewCountry("PL", "POLAND")),
+		must(model.NewCountry("BG", "BULGARIA")),
+		must(model.NewCountry("DE", "GERMANY")),
+	}))
+	assert.NoError(t, r.BankUnits.BulkCreate(ctx, []*model.BankUnit{
+		must(model.NewBankUnit(hqCode, "PL", "POLAND", hqAddress, "PKO BANK POLSKI S.A.", true)),
+		must(model.NewBankUnit(branchCode, "PL", "POLAND", "WARSZAWA, MAZOWIECKIE", "PKO BANK POLSKI S.A.", false)),
+		must(model.NewBankUnit(gdyniaCode, "PL", "POLAND", gdyniaStreet, "PKO BANK POLSKI S.A.", false)),
+		must(model.NewBankUnit(bulgarianHQ, "BG", "BULGARIA", "VISKIAR PLANINA 19 FLOOR 2 SOFIA, SOFIA, 1407", "BENCHMARK FINANCE", true)),
+	}))
+}
+
+func testCountries(t *testing.T, r Repos) {
+	ctx := context.Background()
+	pl := must(model.NewCountry("PL", "POLAND"))
+	fr := must(model.NewCountry("FR", "FRANCE"))
+
+	assert.NoError(t, r.Countries.BulkCreate(ctx, []model.Country{pl}))
+
+	country, err := r.Countries.GetByCode(ctx, pl.Code)
+	assert.NoError(t, err)
+	assert.Equal(t, pl, country)
+	_, err = r.Countries.GetByCode(ctx, fr.Code)
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+
+	exists, err := r.Countries.Exists(ctx, pl)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = r.Countries.Exists(ctx, must(model.NewCountry("PL", "POLSKA")))
+	assert.NoError(t, err)
+	assert.False(t, exists, "the name must match as well")
+
+	err = r.Countries.BulkCreate(ctx, []model.Country{fr, pl})
+	assert.ErrorIs(t, err, repo.ErrDuplicate)
+	_, err = r.Countries.GetByCode(ctx, fr.Code)
+	assert.ErrorIs(t, err, repo.ErrNotFound, "nothing is created when one of the countries exists")
+
+	polska := must(model.NewCountry("PL", "POLSKA"))
+	changed, err := r.Countries.BulkUpsert(ctx, []model.Country{polska, fr, fr})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, changed)
+	changed, err = r.Countries.BulkUpsert(ctx, []model.Country{polska, fr})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, changed)
+
+	countries, err := r.Countries.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []model.Country{polska, fr}, countries)
+}
+
+func testCreateAndGet(t *testing.T, r Repos) {
+	ctx := context.Background()
+	seed(t, r)
+
+	hq, err := r.BankUnits.GetBySwiftCode(ctx, code(hqCode), repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, hqCode, hq.SwiftCode.String())
+	assert.Equal(t, must(model.NewCountry("PL", "POLAND")), hq.Country)
+	assert.Equal(t, hqAddress, hq.Address)
+	assert.True(t, hq.IsHeadquarter)
+	assert.Nil(t, hq.DeletedAt)
+	assert.Nil(t, hq.ValidTo)
+	assert.False(t, hq.ValidFrom.IsZero())
+
+	bic8, err := r.BankUnits.GetBySwiftCode(ctx, code("BPKOPLPW"), repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, hqCode, bic8.SwiftCode.String())
+
+	_, err = r.BankUnits.GetBySwiftCode(ctx, code(unknownCode), repo.ReadOptions{})
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+
+	deutsche := must(model.NewBankUnit(unknownCode, "DE", "GERMANY", "FRANKFURT", "DEUTSCHE BANK AG", true))
+	err = r.BankUnits.Create(ctx, must(model.NewBankUnit(hqCode, "PL", "POLAND", "WARSZAWA", "PKO BP", true)))
+	assert.ErrorIs(t, err, repo.ErrDuplicate)
+	err = r.BankUnits.BulkCreate(ctx, []*model.BankUnit{deutsche, deutsche})
+	assert.ErrorIs(t, err, repo.ErrDuplicate)
+	_, err = r.BankUnits.GetBySwiftCode(ctx, deutsche.SwiftCode, repo.ReadOptions{})
+	assert.ErrorIs(t, err, repo.ErrNotFound, "nothing is created when one of the bank units is a duplicate")
+
+	err = r.BankUnits.Create(ctx, must(model.NewBankUnit("BNPAFRPPXXX", "FR", "FRANCE", "PARIS", "BNP PARIBAS", true)))
+	assert.ErrorIs(t, err, repo.ErrUnknownCountry)
+
+	assert.NoError(t, r.BankUnits.Create(ctx, deutsche))
+	created, err := r.BankUnits.GetBySwiftCode(ctx, deutsche.SwiftCode, repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "DEUTSCHE BANK AG", created.Name)
+	assert.Equal(t, "GERMANY", created.Country.Name)
+
+	units, err := r.BankUnits.GetBySwiftCodes(ctx, []model.SwiftCode{code(hqCode), code(bulgarianHQ), code("BNPAFRPPXXX")})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{hqCode, bulgarianHQ}, swiftCodes(units))
+
+	all, err := r.BankUnits.GetAll(ctx, repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, all, 5)
+}
+
+func testBranches(t *testing.T, r Repos) {
+	ctx := context.Background()
+	seed(t, r)
+
+	branches, err := r.BankUnits.GetBranches(ctx, code(hqCode), repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{branchCode, gdyniaCode}, swiftCodes(branches))
+	for _, branch := range branches {
+		assert.Equal(t, "POLAND", branch.Country.Name)
+	}
+
+	branches, err = r.BankUnits.GetBranches(ctx, code(bulgarianHQ), repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, branches)
+
+	branches, err = r.BankUnits.GetBranchesOf(ctx, []model.SwiftCode{code(hqCode), code(bulgarianHQ)})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{branchCode, gdyniaCode}, swiftCodes(branches))
+
+	assert.NoError(t, r.BankUnits.Delete(ctx, code(branchCode), repo.DeleteOptions{}))
+	branches, err = r.BankUnits.GetBranches(ctx, code(hqCode), repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{gdyniaCode}, swiftCodes(branches))
+	branches, err = r.BankUnits.GetBranches(ctx, code(hqCode), repo.ReadOptions{IncludeDeleted: true})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{branchCode, gdyniaCode}, swiftCodes(branches))
+}
+
+func testUpdate(t *testing.T, r Repos) {
+	ctx := context.Background()
+	seed(t, r)
+
+	before, err := r.BankUnits.GetBySwiftCode(ctx, code(gdyniaCode), repo.ReadOptions{})
+	assert.NoError(t, err)
+
+	updated := must(model.NewBankUnit(gdyniaCode, "PL", "POLAND", "SWIETOJANSKA 19  GDYNIA", "PKO BP", false))
+	updated.TownName = "GDYNIA"
+	updated.TimeZone = "Europe/Warsaw"
+	assert.NoError(t, r.BankUnits.Update(ctx, updated))
+
+	after, err := r.BankUnits.GetBySwiftCode(ctx, code(gdyniaCode), repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "PKO BP", after.Name)
+	assert.Equal(t, "SWIETOJANSKA 19  GDYNIA", after.Address)
+	assert.Equal(t, "GDYNIA", after.TownName)
+	assert.Equal(t, "Europe/Warsaw", after.TimeZone)
+	assert.Equal(t, "POLAND", after.Country.Name)
+	assert.False(t, after.ValidFrom.Before(before.ValidFrom), "the new version starts after the previous one")
+
+	all, err := r.BankUnits.GetAll(ctx, repo.ReadOptions{IncludeDeleted: true})
+	assert.NoError(t, err)
+	assert.Len(t, all, 4, "only current versions are read")
+
+	err = r.BankUnits.Update(ctx, must(model.NewBankUnit(unknownCode, "DE", "GERMANY", "FRANKFURT", "DEUTSCHE BANK AG", true)))
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+
+	assert.NoError(t, r.BankUnits.Delete(ctx, code(gdyniaCode), repo.DeleteOptions{}))
+	err = r.BankUnits.Update(ctx, updated)
+	assert.ErrorIs(t, err, repo.ErrNotFound, "deleted bank units cannot be updated")
+}
+
+func testDelete(t *testing.T, r Repos) {
+	ctx := context.Background()
+	seed(t, r)
+
+	err := r.BankUnits.Delete(ctx, code(hqCode), repo.DeleteOptions{})
+	assert.ErrorIs(t, err, repo.ErrHasBranches)
+	_, err = r.BankUnits.GetBySwiftCode(ctx, code(hqCode), repo.ReadOptions{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.BankUnits.Delete(ctx, code(branchCode), repo.DeleteOptions{}))
+	_, err = r.BankUnits.GetBySwiftCode(ctx, code(branchCode), repo.ReadOptions{})
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+	deleted, err := r.BankUnits.GetBySwiftCode(ctx, code(branchCode), repo.ReadOptions{IncludeDeleted: true})
+	assert.NoError(t, err)
+	assert.NotNil(t, deleted.DeletedAt)
+	assert.Equal(t, "POLAND", deleted.Country.Name)
+
+	err = r.BankUnits.Delete(ctx, code(branchCode), repo.DeleteOptions{})
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+	err = r.BankUnits.Delete(ctx, code(unknownCode), repo.DeleteOptions{})
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+
+	// the cascade must be deleted later than the branch for the order checked below
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, r.BankUnits.Delete(ctx, code(hqCode), repo.DeleteOptions{Cascade: true}))
+	for _, c := range []string{hqCode, gdyniaCode} {
+		_, err = r.BankUnits.GetBySwiftCode(ctx, code(c), repo.ReadOptions{})
+		assert.ErrorIs(t, err, repo.ErrNotFound, c)
+	}
+
+	// the cascade deletes in one go, ties are ordered by the most recently created first
+	units, err := r.BankUnits.GetDeleted(ctx, repo.DeletedListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{gdyniaCode, hqCode, branchCode}, swiftCodes(units))
+
+	units, err = r.BankUnits.GetDeleted(ctx, repo.DeletedListOptions{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{gdyniaCode}, swiftCodes(units))
+
+	units, err = r.BankUnits.GetDeleted(ctx, repo.DeletedListOptions{Since: *units[0].DeletedAt})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{gdyniaCode, hqCode}, swiftCodes(units))
+
+	all, err := r.BankUnits.GetAll(ctx, repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{bulgarianHQ}, swiftCodes(all))
+	all, err = r.BankUnits.GetAll(ctx, repo.ReadOptions{IncludeDeleted: true})
+	assert.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	assert.NoError(t, r.BankUnits.DeleteAll(ctx))
+	all, err = r.BankUnits.GetAll(ctx, repo.ReadOptions{IncludeDeleted: true})
+	assert.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+func testRestore(t *testing.T, r Repos) {
+	ctx := context.Background()
+	seed(t, r)
+
+	_, err := r.BankUnits.Restore(ctx, code(branchCode))
+	assert.ErrorIs(t, err, repo.ErrNotFound, "live bank units cannot be restored")
+	_, err = r.BankUnits.Restore(ctx, code(unknownCode))
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+
+	assert.NoError(t, r.BankUnits.Delete(ctx, code(branchCode), repo.DeleteOptions{}))
+	restored, err := r.BankUnits.Restore(ctx, code(branchCode))
+	assert.NoError(t, err)
+	assert.Equal(t, branchCode, restored.SwiftCode.String())
+	assert.Equal(t, "POLAND", restored.Country.Name)
+	assert.Nil(t, restored.DeletedAt)
+
+	live, err := r.BankUnits.GetBySwiftCode(ctx, code(branchCode), repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, restored, live)
+
+	deleted, err := r.BankUnits.GetDeleted(ctx, repo.DeletedListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, deleted, "restored bank units are not listed as deleted")
+
+	assert.NoError(t, r.BankUnits.Delete(ctx, code(branchCode), repo.DeleteOptions{}))
+	assert.NoError(t, r.BankUnits.Create(ctx, must(model.NewBankUnit(branchCode, "PL", "POLAND", "WARSZAWA", "PKO BP", false))))
+	_, err = r.BankUnits.Restore(ctx, code(branchCode))
+	assert.ErrorIs(t, err, repo.ErrDuplicate)
+
+	live, err = r.BankUnits.GetBySwiftCode(ctx, code(branchCode), repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "PKO BP", live.Name, "a failed restore changes nothing")
+}
+
+func testList(t *testing.T, r Repos) {
+	ctx := context.Background()
+	seed(t, r)
+	assert.NoError(t, r.BankUnits.BulkCreate(ctx, []*model.BankUnit{
+		must(model.NewBankUnit("DEUTDEFFXXX", "DE", "GERMANY", "FRANKFURT", "DEUTSCHE BANK AG", true)),
+		must(model.NewBankUnit("DEUTDEFF500", "DE", "GERMANY", "FRANKFURT", "DEUTSCHE BANK AG", false)),
+		must(model.NewBankUnit("DEUTDEDBXXX", "DE", "GERMANY", "BERLIN", "DEUTSCHE BANK AG", true)),
+		must(model.NewBankUnit("DEUTDEF0XXX", "DE", "GERMANY", "FRANKFURT", "DEUTSCHE BANK TEST", true)),
+		must(model.NewBankUnit("DRESDEFFXXX", "DE", "GERMANY", "FRANKFURT", "COMMERZBANK", true)),
+	}))
+	de := must(model.NewCountryISO2("DE"))
+
+	units, err := r.BankUnits.GetAllByCountry(ctx, de, repo.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"DEUTDEDBXXX", "DEUTDEF0XXX", "DEUTDEFF500", "DEUTDEFFXXX", "DRESDEFFXXX"}, swiftCodes(units))
+	assert.Equal(t, "GERMANY", units[0].Country.Name)
+
+	units, err = r.BankUnits.GetAllByCountry(ctx, de, repo.ListOptions{ExcludeTestBICs: true, After: "DEUTDEDBXXX", Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"DEUTDEFF500", "DEUTDEFFXXX"}, swiftCodes(units))
+
+	units, err = r.BankUnits.GetAllByCountry(ctx, must(model.NewCountryISO2("FR")), repo.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, units)
+
+	tests := []struct {
+		pattern string
+		opts    repo.ListOptions
+		want    []string
+	}{
+		{"DEUT", repo.ListOptions{}, []string{"DEUTDEDBXXX", "DEUTDEF0XXX", "DEUTDEFF500", "DEUTDEFFXXX"}},
+		{"DEUTDEFF", repo.ListOptions{}, []string{"DEUTDEFF500", "DEUTDEFFXXX"}},
+		{"????DEFF", repo.ListOptions{}, []string{"DEUTDEFF500", "DEUTDEFFXXX", "DRESDEFFXXX"}},
+		{"????DE??XXX", repo.ListOptions{ExcludeTestBICs: true}, []string{"DEUTDEDBXXX", "DEUTDEFFXXX", "DRESDEFFXXX"}},
+		{"????DE??XXX", repo.ListOptions{After: "DEUTDEFFXXX"}, []string{"DRESDEFFXXX"}},
+		{"DEUTDEFFXXX", repo.ListOptions{}, []string{"DEUTDEFFXXX"}},
+		{"BPKOPL", repo.ListOptions{Limit: 1}, []string{branchCode}},
+		{"BNPAFR", repo.ListOptions{}, nil},
+	}
+	for _, tt := range tests {
+		units, err := r.BankUnits.FindByPattern(ctx, must(model.NewSwiftCodePattern(tt.pattern)), tt.opts)
+		assert.NoError(t, err, tt.pattern)
+		assert.Equal(t, tt.want, swiftCodes(units), tt.pattern)
+	}
+
+	assert.NoError(t, r.BankUnits.Delete(ctx, code("DEUTDEFF500"), repo.DeleteOptions{}))
+	units, err = r.BankUnits.FindByPattern(ctx, must(model.NewSwiftCodePattern("DEUTDEFF")), repo.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"DEUTDEFFXXX"}, swiftCodes(units))
+}
+
+func testBulk(t *testing.T, r Repos) {
+	ctx := context.Background()
+	seed(t, r)
+
+	deutsche := must(model.NewBankUnit(unknownCode, "DE", "GERMANY", "FRANKFURT", "DEUTSCHE BANK AG", true))
+	commerz := must(model.NewBankUnit("COBADEFFXXX", "DE", "GERMANY", "FRANKFURT", "COMMERZBANK", true))
+
+	created, err := r.BankUnits.BulkCreateFrom(ctx, units(deutsche, commerz))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, created)
+
+	_, err = r.BankUnits.BulkCreateFrom(ctx, units(must(model.NewBankUnit("DRESDEFFXXX", "DE", "GERMANY", "FRANKFURT", "DRESDNER", true)), deutsche))
+	assert.ErrorIs(t, err, repo.ErrDuplicate)
+	_, err = r.BankUnits.BulkCreateFrom(ctx, failingUnits(must(model.NewBankUnit("DRESDEFFXXX", "DE", "GERMANY", "FRANKFURT", "DRESDNER", true))))
+	assert.Error(t, err)
+	_, err = r.BankUnits.GetBySwiftCode(ctx, code("DRESDEFFXXX"), repo.ReadOptions{})
+	assert.ErrorIs(t, err, repo.ErrNotFound, "nothing is created when the import fails")
+
+	unchanged := must(model.NewBankUnit(hqCode, "PL", "POLAND", hqAddress, "PKO BANK POLSKI S.A.", true))
+	renamed := must(model.NewBankUnit(branchCode, "PL", "POLAND", "WARSZAWA, MAZOWIECKIE", "PKO BP", false))
+	added := must(model.NewBankUnit("DRESDEFFXXX", "DE", "GERMANY", "FRANKFURT", "DRESDNER", true))
+	changed, err := r.BankUnits.BulkUpsert(ctx, units(unchanged, renamed, added, added))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, changed)
+
+	branch, err := r.BankUnits.GetBySwiftCode(ctx, code(branchCode), repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "PKO BP", branch.Name)
+	_, err = r.BankUnits.GetBySwiftCode(ctx, code("DRESDEFFXXX"), repo.ReadOptions{})
+	assert.NoError(t, err)
+
+	changed, err = r.BankUnits.BulkUpsert(ctx, units(unchanged, renamed, added))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, changed)
+
+	_, err = r.BankUnits.BulkUpsert(ctx, failingUnits(must(model.NewBankUnit(gdyniaCode, "PL", "POLAND", "GDYNIA", "PKO BP", false))))
+	assert.Error(t, err)
+	gdynia, err := r.BankUnits.GetBySwiftCode(ctx, code(gdyniaCode), repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "PKO BANK POLSKI S.A.", gdynia.Name, "nothing is changed when the import fails")
+
+	_, err = r.BankUnits.BulkUpsert(ctx, units(must(model.NewBankUnit("BNPAFRPPXXX", "FR", "FRANCE", "PARIS", "BNP PARIBAS", true))))
+	assert.ErrorIs(t, err, repo.ErrUnknownCountry)
+}
+
+func testChangeset(t *testing.T, r Repos) {
+	ctx := context.Background()
+	seed(t, r)
+
+	deutsche := must(model.NewBankUnit(unknownCode, "DE", "GERMANY", "FRANKFURT", "DEUTSCHE BANK AG", true))
+	renamed := must(model.NewBankUnit(branchCode, "PL", "POLAND", "WARSZAWA, MAZOWIECKIE", "PKO BP", false))
+
+	err := r.BankUnits.ApplyChangeset(ctx, repo.Changeset{
+		Create: []*model.BankUnit{deutsche},
+		Update: []*model.BankUnit{must(model.NewBankUnit("BPKOPLPWWAW", "PL", "POLAND", "WARSZAWA", "PKO BP", false))},
+		Delete: []model.SwiftCode{code(gdyniaCode)},
+	})
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+	_, err = r.BankUnits.GetBySwiftCode(ctx, code(gdyniaCode), repo.ReadOptions{})
+	assert.NoError(t, err, "nothing is changed when one of the changes fails")
+	_, err = r.BankUnits.GetBySwiftCode(ctx, deutsche.SwiftCode, repo.ReadOptions{})
+	assert.ErrorIs(t, err, repo.ErrNotFound, "nothing is changed when one of the changes fails")
+
+	movedGdynia := must(model.NewBankUnit(gdyniaCode, "PL", "POLAND", "10 LUTEGO 1  GDYNIA", "PKO BANK POLSKI S.A.", false))
+	err = r.BankUnits.ApplyChangeset(ctx, repo.Changeset{
+		Create: []*model.BankUnit{deutsche, movedGdynia},
+		Update: []*model.BankUnit{renamed},
+		Delete: []model.SwiftCode{code(gdyniaCode), code(bulgarianHQ), code("BNPAFRPPXXX")},
+	})
+	assert.NoError(t, err)
+
+	_, err = r.BankUnits.GetBySwiftCode(ctx, code(bulgarianHQ), repo.ReadOptions{})
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+	branch, err := r.BankUnits.GetBySwiftCode(ctx, code(branchCode), repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "PKO BP", branch.Name)
+	_, err = r.BankUnits.GetBySwiftCode(ctx, deutsche.SwiftCode, repo.ReadOptions{})
+	assert.NoError(t, err)
+	gdynia, err := r.BankUnits.GetBySwiftCode(ctx, code(gdyniaCode), repo.ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "10 LUTEGO 1  GDYNIA", gdynia.Address, "deletes apply before creates")
+
+	err = r.BankUnits.ApplyChangeset(ctx, repo.Changeset{Create: []*model.BankUnit{deutsche}})
+	assert.ErrorIs(t, err, repo.ErrDuplicate)
+}
+
+func testPointInTime(t *testing.T, r Repos) {
+	ctx := context.Background()
+	seed(t, r)
+	read := func(swiftCode string, asOf time.Time) (*model.BankUnit, error) {
+		return r.BankUnits.GetBySwiftCode(ctx, code(swiftCode), repo.ReadOptions{AsOf: asOf})
+	}
+
+	created, err := read(gdyniaCode, time.Time{})
+	assert.NoError(t, err)
+	_, err = read(gdyniaCode, created.ValidFrom.Add(-time.Microsecond))
+	assert.ErrorIs(t, err, repo.ErrNotFound, "the bank unit did not exist yet")
+
+	// versions written within the same microsecond could not be told apart
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, r.BankUnits.Update(ctx, must(model.NewBankUnit(gdyniaCode, "PL", "POLAND", gdyniaStreet, "PKO BP", false))))
+	updated, err := read(gdyniaCode, time.Time{})
+	assert.NoError(t, err)
+
+	before, err := read(gdyniaCode, updated.ValidFrom.Add(-time.Microsecond))
+	assert.NoError(t, err)
+	assert.Equal(t, "PKO BANK POLSKI S.A.", before.Name)
+	assert.Equal(t, created.ValidFrom, before.ValidFrom)
+	assert.Equal(t, updated.ValidFrom, *before.ValidTo)
+	assert.Equal(t, "POLAND", before.Country.Name)
+	after, err := read(gdyniaCode, updated.ValidFrom)
+	assert.NoError(t, err)
+	assert.Equal(t, "PKO BP", after.Name)
+
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, r.BankUnits.Delete(ctx, code(hqCode), repo.DeleteOptions{Cascade: true}))
+	deleted, err := r.BankUnits.GetBySwiftCode(ctx, code(hqCode), repo.ReadOptions{IncludeDeleted: true})
+	assert.NoError(t, err)
+	beforeDelete := deleted.DeletedAt.Add(-time.Microsecond)
+
+	hq, err := read(hqCode, beforeDelete)
+	assert.NoError(t, err)
+	assert.Nil(t, hq.DeletedAt, "as of then the bank unit was not deleted")
+	_, err = read(hqCode, *deleted.DeletedAt)
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+
+	branches, err := r.BankUnits.GetBranches(ctx, code(hqCode), repo.ReadOptions{AsOf: beforeDelete})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{branchCode, gdyniaCode}, swiftCodes(branches))
+	units, err := r.BankUnits.GetAllByCountry(ctx, must(model.NewCountryISO2("PL")), repo.ListOptions{AsOf: beforeDelete})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{branchCode, gdyniaCode, hqCode}, swiftCodes(units))
+	units, err = r.BankUnits.FindByPattern(ctx, must(model.NewSwiftCodePattern("BPKOPLPW")), repo.ListOptions{AsOf: *deleted.DeletedAt})
+	assert.NoError(t, err)
+	assert.Empty(t, units)
+}
+
+func testSearch(t *testing.T, r Repos) {
+	ctx := context.Background()
+	seed(t, r)
+	search := func(query repo.SearchQuery) []string {
+		results, err := r.BankUnits.Search(ctx, query)
+		assert.NoError(t, err)
+		codes := []string{}
+		for i, result := range results {
+			assert.Greater(t, result.Score, 0.0)
+			if i > 0 {
+				assert.GreaterOrEqual(t, results[i-1].Score, result.Score)
+			}
+			codes = append(codes, result.BankUnit.SwiftCode.String())
+		}
+		return codes
+	}
+
+	assert.Equal(t, []string{gdyniaCode}, search(repo.SearchQuery{Text: "gdynia"}))
+	assert.Equal(t, []string{gdyniaCode}, search(repo.SearchQuery{Text: "Świętojańska"}), "accents are ignored")
+	assert.ElementsMatch(t, []string{hqCode, branchCode}, search(repo.SearchQuery{Text: "warszwa"}), "typos are tolerated")
+	assert.Equal(t, []string{bulgarianHQ}, search(repo.SearchQuery{Text: "benchmark"}))
+	assert.Empty(t, search(repo.SearchQuery{Text: "benchmark", CountryISO2: must(model.NewCountryISO2("PL"))}))
+	assert.Equal(t, []string{hqCode}, search(repo.SearchQuery{Text: "pko", HeadquartersOnly: true}))
+	assert.Len(t, search(repo.SearchQuery{Text: "pko", Limit: 2}), 2)
+
+	assert.NoError(t, r.BankUnits.Delete(ctx, code(gdyniaCode), repo.DeleteOptions{}))
+	assert.Empty(t, search(repo.SearchQuery{Text: "gdynia"}), "deleted bank units are not found")
+}
+
+func testAudit(t *testing.T, r Repos) {
+	ctx := audit.WithRequestID(audit.WithActor(context.Background(), "alice"), "req-1")
+	seed(t, r)
+
+	renamed := must(model.NewBankUnit(branchCode, "PL", "POLAND", "WARSZAWA, MAZOWIECKIE", "PKO BP", false))
+	assert.NoError(t, r.BankUnits.Update(ctx, renamed))
+	assert.NoError(t, r.BankUnits.Delete(ctx, code(hqCode), repo.DeleteOptions{Cascade: true}))
+	_, err := r.BankUnits.Restore(ctx, code(hqCode))
+	assert.NoError(t, err)
+	assert.Error(t, r.BankUnits.Create(ctx, must(model.NewBankUnit(bulgarianHQ, "BG", "BULGARIA", "SOFIA", "BENCHMARK", true))))
+
+	events, err := r.Audit.List(ctx, repo.AuditFilter{Actor: "alice"})
+	assert.NoError(t, err)
+	var actions []model.AuditAction
+	for _, event := range events {
+		actions = append(actions, event.Action)
+		assert.Equal(t, "req-1", event.RequestID)
+	}
+	assert.Equal(t, []model.AuditAction{
+		model.AuditRestore, model.AuditDelete, model.AuditDelete, model.AuditDelete, model.AuditUpdate,
+	}, actions, "the most recent first, failed writes are not recorded")
+
+	update := events[len(events)-1]
+	assert.Equal(t, branchCode, update.SwiftCode.String())
+	assert.Equal(t, "PKO BANK POLSKI S.A.", update.Before.Name)
+	assert.Equal(t, "POLAND", update.Before.Country.Name)
+	assert.Equal(t, "PKO BP", update.After.Name)
+	assert.Nil(t, events[0].Before)
+	assert.Equal(t, hqCode, events[0].After.SwiftCode.String())
+
+	events, err = r.Audit.List(ctx, repo.AuditFilter{SwiftCode: branchCode})
+	assert.NoError(t, err)
+	assert.Len(t, events, 3, "created, updated and deleted")
+	events, err = r.Audit.List(ctx, repo.AuditFilter{SwiftCode: branchCode, BeforeID: events[0].ID, Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, model.AuditUpdate, events[0].Action)
+
+	// ids are bigint, cursors beyond the int4 range must work as well
+	events, err = r.Audit.List(ctx, repo.AuditFilter{SwiftCode: branchCode, BeforeID: 1 << 40})
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func code(s string) model.SwiftCode {
+	return must(model.NewSwiftCode(s))
+}
+
+func swiftCodes(bankUnits []*model.BankUnit) []string {
+	var codes []string
+	for _, bankUnit := range bankUnits {
+		codes = append(codes, bankUnit.SwiftCode.String())
+	}
+	return codes
+}
+
+func units(bankUnits ...*model.BankUnit) iter.Seq2[*model.BankUnit, error] {
+	return func(yield func(*model.BankUnit, error) bool) {
+		for _, bankUnit := range bankUnits {
+			if !yield(bankUnit, nil) {
+				return
+			}
+		}
+	}
+}
+
+// failingUnits yields the bank units followed by an error, like an import of a
+// file with an invalid row at the end.
+func failingUnits(bankUnits ...*model.BankUnit) iter.Seq2[*model.BankUnit, error] {
+	return func(yield func(*model.BankUnit, error) bool) {
+		for bankUnit, err := range units(bankUnits...) {
+			if !yield(bankUnit, err) {
+				return
+			}
+		}
+		yield(nil, errors.New("invalid row"))
+	}
+}